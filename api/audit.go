@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"regatta-project/pkg/repository"
+
+	"github.com/gorilla/mux"
+)
+
+// Actions recorded in the results history
+const (
+	ResultCreated  = repository.ResultCreated
+	ResultAmended  = repository.ResultAmended
+	ResultDeleted  = repository.ResultDeleted
+	ResultDecision = repository.ResultDecision
+)
+
+// Types
+
+type ResultChange = repository.ResultChange
+type StandingsVersion = repository.StandingsVersion
+
+// resultLog collects the changes a request makes to the race results of a
+// regatta. record writes them together with the results, as a new version
+// of the standings attributed to the signed in user and explained by
+// X-Change-Reason.
+type resultLog struct {
+	regattaId string
+	by        string
+	reason    string
+	changes   []ResultChange
+}
+
+func newResultLog(r *http.Request, regattaId string) *resultLog {
+	return &resultLog{
+		regattaId: regattaId,
+		by:        changedBy(r),
+		reason:    r.Header.Get("X-Change-Reason"),
+	}
+}
+
+// created records a new result; its values are read when committed.
+func (l *resultLog) created(result RaceResult) {
+	l.changes = append(l.changes, ResultChange{ResultID: result.ID, TeamID: result.TeamID, RaceNumber: result.RaceNumber, Action: ResultCreated})
+}
+
+// amended records a change to a result, given its values before the change.
+func (l *resultLog) amended(action string, before RaceResult) {
+	l.changes = append(l.changes, ResultChange{ResultID: before.ID, TeamID: before.TeamID, RaceNumber: before.RaceNumber, Action: action, Before: &before})
+}
+
+// deleted records results that have been deleted.
+func (l *resultLog) deleted(results []RaceResult) {
+	for i := range results {
+		l.changes = append(l.changes, ResultChange{ResultID: results[i].ID, TeamID: results[i].TeamID, RaceNumber: results[i].RaceNumber,
+			Action: ResultDeleted, Before: &results[i]})
+	}
+}
+
+// record runs write, which changes the results of the regatta through store
+// and records each change in the log, and commits the changes in the same
+// transaction. The transaction holds the regatta, so requests changing its
+// results are stored and numbered one after another. The new standings are
+// pushed to the standings stream once stored.
+func (l *resultLog) record(write func(store *repository.Repository) error) error {
+	var event *StandingsEvent
+	err := repo.Transaction(func(store *repository.Repository) error {
+		if err := store.LockRegatta(l.regattaId); err != nil {
+			return err
+		}
+		if err := write(store); err != nil {
+			return err
+		}
+
+		var err error
+		event, err = l.commit(store)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if event != nil {
+		standingsStream.broadcast(*event)
+	}
+	l.changes = nil
+	return nil
+}
+
+// commit stores the collected changes, with the values of every result that
+// still exists as they are now, and snapshots the standings they produce as
+// the regatta's next version. It returns the event announcing them, or nil
+// when nothing changed.
+func (l *resultLog) commit(store *repository.Repository) (*StandingsEvent, error) {
+	if len(l.changes) == 0 {
+		return nil, nil
+	}
+
+	standings, err := computeStandings(store, l.regattaId)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := json.Marshal(standings)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := store.NextStandingsVersion(l.regattaId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	err = store.InsertStandingsVersion(l.regattaId, StandingsVersion{Version: version, ChangedBy: l.by, Reason: l.reason, CreatedAt: now, Standings: snapshot})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range l.changes {
+		change := &l.changes[i]
+		change.Version = version
+		change.ChangedBy = l.by
+		change.Reason = l.reason
+		change.ChangedAt = now
+
+		if change.Action != ResultDeleted {
+			result, err := store.GetResult(change.ResultID)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return nil, err
+			}
+			if err == nil {
+				change.After = &result
+			}
+		}
+
+		if err := store.InsertResultChange(l.regattaId, *change); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Printf("Recorded %d result changes as version %d of RegattaID: %s", len(l.changes), version, l.regattaId)
+	return &StandingsEvent{Type: StreamResults, RegattaID: l.regattaId, Version: version, Changes: l.changes, Standings: standings}, nil
+}
+
+// getResultHistory lists every recorded change to the results of a regatta,
+// oldest first. ?raceNumber= and ?teamId= narrow the list.
+func getResultHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	var raceNumber int
+	if value := r.URL.Query().Get("raceNumber"); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			httpError(w, "raceNumber must be 1 or greater", http.StatusBadRequest)
+			return
+		}
+		raceNumber = number
+	}
+
+	changes, err := repo.ListResultChanges(regattaId, r.URL.Query().Get("teamId"), raceNumber)
+	if err != nil {
+		log.Printf("Error fetching result history: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
+
+// getStandingsVersions lists the versions of a regatta's standings without
+// their contents, oldest first.
+func getStandingsVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	versions, err := repo.ListStandingsVersions(regattaId)
+	if err != nil {
+		log.Printf("Error fetching standings versions: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+// getStandingsVersion serves the standings of a regatta as they stood at a
+// version.
+func getStandingsVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	number, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		httpError(w, "version must be a number", http.StatusBadRequest)
+		return
+	}
+
+	version, err := repo.GetStandingsVersion(regattaId, number)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(version)
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"regatta-project/pkg/repository"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Roles of user accounts. An admin may do anything; requests without a
+// token act as the read-only public.
+const (
+	RoleAdmin       = "ADMIN"
+	RoleRaceOfficer = "RACE_OFFICER"
+	RoleScorer      = "SCORER"
+	RoleJury        = "JURY"
+	RolePublic      = "PUBLIC"
+)
+
+var roles = []string{RoleAdmin, RoleRaceOfficer, RoleScorer, RoleJury, RolePublic}
+
+// staffRoles run the regatta and may see results before they are published.
+var staffRoles = []string{RoleRaceOfficer, RoleScorer, RoleJury}
+
+// sessionDuration is how long a login token stays valid.
+const sessionDuration = 12 * time.Hour
+
+const minPasswordLength = 8
+
+// Types
+
+type User = repository.User
+
+// Session is returned by a login: the bearer token to send as
+// "Authorization: Bearer <token>" until it expires.
+type Session struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	User      User      `json:"user"`
+}
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// authenticate resolves the bearer token of a request to its user. Requests
+// without a token go on as the public; an unknown or expired token is
+// rejected so the client knows to sign in again.
+func authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			httpError(w, "Authorization must be a bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := repo.SessionUser(hashToken(token), time.Now().UTC())
+		if errors.Is(err, repository.ErrNotFound) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			httpError(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			log.Printf("Error checking session: %v", err)
+			writeError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey, &user)))
+	})
+}
+
+// requireRole lets a handler be called only by users with one of the given
+// roles, or by an admin.
+func requireRole(handler http.HandlerFunc, allowed ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := currentUser(r)
+		if user == nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			httpError(w, "Sign in to do this", http.StatusUnauthorized)
+			return
+		}
+		if user.Role != RoleAdmin && !slices.Contains(allowed, user.Role) {
+			log.Printf("User %s (%s) denied %s %s", user.Username, user.Role, r.Method, r.URL.Path)
+			httpError(w, "Your role does not allow this", http.StatusForbidden)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// currentUser returns the signed in user of a request, or nil for the
+// public.
+func currentUser(r *http.Request) *User {
+	user, _ := r.Context().Value(userContextKey).(*User)
+	return user
+}
+
+// seesWorkingResults reports whether the user of a request may see the
+// working results, rather than only what has been published.
+func seesWorkingResults(r *http.Request) bool {
+	user := currentUser(r)
+	return user != nil && (user.Role == RoleAdmin || slices.Contains(staffRoles, user.Role))
+}
+
+// changedBy names the signed in user making a change. Every route that
+// changes results requires a session, so the name is never taken from the
+// client.
+func changedBy(r *http.Request) string {
+	if user := currentUser(r); user != nil {
+		return user.Username
+	}
+	return ""
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// login checks a username and password and starts a session.
+func login(w http.ResponseWriter, r *http.Request) {
+	var credentials struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, hash, err := repo.UserCredentials(strings.TrimSpace(credentials.Username))
+	missing := errors.Is(err, repository.ErrNotFound)
+	if err != nil && !missing {
+		writeError(w, err)
+		return
+	}
+	if missing || bcrypt.CompareHashAndPassword([]byte(hash), []byte(credentials.Password)) != nil {
+		log.Printf("Failed login for user %q", credentials.Username)
+		httpError(w, "Invalid username or password", http.StatusUnauthorized)
+		return
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	session := Session{Token: hex.EncodeToString(secret), ExpiresAt: now.Add(sessionDuration), User: user}
+	if err := repo.CreateSession(hashToken(session.Token), user.ID, now, session.ExpiresAt); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Expired sessions are of no further use
+	if err := repo.DeleteExpiredSessions(now); err != nil {
+		log.Printf("Error removing expired sessions: %v", err)
+	}
+
+	log.Printf("User %s signed in", user.Username)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// logout ends the session of the request's token.
+func logout(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if err := repo.DeleteSession(hashToken(token)); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getCurrentUser returns the signed in user.
+func getCurrentUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(currentUser(r))
+}
+
+// getUsers lists the users of the admin's organisation, or every user for
+// a site admin.
+func getUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := repo.ListUsers(requestOrganisation(r))
+	if err != nil {
+		log.Printf("Error fetching users: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
+// createUser adds a user to the admin's organisation. A site admin may name
+// any organisation, or none for another site admin.
+func createUser(w http.ResponseWriter, r *http.Request) {
+	var user User
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	organisationId, err := organisationForCreate(r, user.OrganisationID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	user.OrganisationID = organisationId
+
+	if err := validateRole(user.Role); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := insertUser(&user); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	log.Printf("Created user %s with role %s", user.Username, user.Role)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+// updateUser changes the role or password of a user. Changing the password
+// signs the user out everywhere.
+func updateUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userId := vars["userId"]
+
+	user, err := repo.GetUser(userId, requestOrganisation(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	role, organisationId, username := user.Role, user.OrganisationID, user.Username
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user.ID = userId
+	user.OrganisationID = organisationId
+	if err := validateRole(user.Role); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if userId == currentUser(r).ID && user.Role != role {
+		httpError(w, "You cannot change your own role", http.StatusConflict)
+		return
+	}
+
+	if user.Password != "" {
+		hash, err := hashPassword(user.Password)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := repo.SetUserPassword(userId, hash); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	// The username identifies the user in the audit trail and is kept
+	if err := repo.SetUserRole(userId, user.Role); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	log.Printf("Updated user %s", userId)
+
+	user.Username = username
+	user.Password = ""
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+func deleteUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userId := vars["userId"]
+
+	if userId == currentUser(r).ID {
+		httpError(w, "You cannot delete your own account", http.StatusConflict)
+		return
+	}
+
+	if _, err := repo.GetUser(userId, requestOrganisation(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := repo.DeleteUser(userId); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateRole(role string) error {
+	if !slices.Contains(roles, role) {
+		return fmt.Errorf("role must be one of %s", strings.Join(roles, ", "))
+	}
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", repository.Validation("password must be at least %d characters", minPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", repository.Validation("%v", err)
+	}
+	return string(hash), nil
+}
+
+// insertUser stores a new user with its hashed password, filling in its ID
+// and creation time and clearing the password.
+func insertUser(user *User) error {
+	hash, err := hashPassword(user.Password)
+	if err != nil {
+		return err
+	}
+	return repo.CreateUser(user, hash)
+}
+
+// bootstrapAdmin creates the first admin from ADMIN_USERNAME and
+// ADMIN_PASSWORD when there are no users yet, so a new deployment can be
+// signed in to.
+func bootstrapAdmin() error {
+	count, err := repo.CountUsers()
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	username := os.Getenv("ADMIN_USERNAME")
+	password := os.Getenv("ADMIN_PASSWORD")
+	if username == "" || password == "" {
+		log.Printf("No users exist; set ADMIN_USERNAME and ADMIN_PASSWORD to create the first admin")
+		return nil
+	}
+
+	user := User{Username: username, Password: password, Role: RoleAdmin}
+	if err := insertUser(&user); err != nil {
+		return err
+	}
+	log.Printf("Created admin user %s", username)
+	return nil
+}
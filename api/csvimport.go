@@ -0,0 +1,392 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"regatta-project/pkg/repository"
+	"regatta-project/pkg/scoring"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Types
+
+// ImportReport is the outcome of a CSV import. Rows are numbered as in the
+// file, the header being row 1. Nothing is written when there are errors or
+// when the import is a dry run.
+type ImportReport struct {
+	DryRun   bool       `json:"dryRun"`
+	Rows     int        `json:"rows"`
+	Imported int        `json:"imported"`
+	Errors   []RowError `json:"errors"`
+}
+
+type RowError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// csvTable is a parsed CSV upload whose header names are matched without
+// regard to case, spaces or underscores.
+type csvTable struct {
+	columns map[string]int
+	rows    [][]string
+}
+
+// importTeamsCSV adds the teams listed in a CSV file to a regatta. The
+// header names the columns: name, sailNumber, boatName, class, helm, crew
+// (separated by semicolons), club, country, fleet (by name) and rating.
+// With ?dryRun=true the rows are only validated.
+func importTeamsCSV(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	log.Printf("importTeamsCSV handler called - RegattaID: %s", regattaId)
+
+	table, err := readCSVUpload(r)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !table.has("name") && !table.has("sailnumber") {
+		httpError(w, "CSV header must include a name or sailNumber column", http.StatusBadRequest)
+		return
+	}
+
+	fleets, err := repo.ListFleets(regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	fleetIds := make(map[string]string)
+	for _, fleet := range fleets {
+		fleetIds[strings.ToLower(fleet.Name)] = fleet.ID
+	}
+
+	report := ImportReport{DryRun: r.URL.Query().Get("dryRun") == "true", Rows: len(table.rows), Errors: []RowError{}}
+	seen := make(map[string]int)
+	var teams []Team
+	for i, row := range table.rows {
+		line := i + 2
+		rowError := func(format string, args ...interface{}) {
+			report.Errors = append(report.Errors, RowError{Row: line, Message: fmt.Sprintf(format, args...)})
+		}
+
+		team := Team{
+			ID:        uuid.New().String(),
+			Name:      strings.TrimSpace(table.get(row, "name")),
+			RegattaID: regattaId,
+			EntryDetails: EntryDetails{
+				SailNumber: table.get(row, "sailnumber"),
+				BoatName:   table.get(row, "boatname"),
+				Class:      table.get(row, "class"),
+				Helm:       table.get(row, "helm"),
+				Crew:       strings.Split(table.get(row, "crew"), ";"),
+				Club:       table.get(row, "club"),
+				Country:    table.get(row, "country"),
+			},
+		}
+		team.Normalize()
+		if team.Name == "" {
+			team.Name = team.BoatName
+		}
+		if team.Name == "" {
+			team.Name = team.SailNumber
+		}
+		if team.Name == "" {
+			rowError("name or sail number is required")
+			continue
+		}
+
+		if err := team.Validate(); err != nil {
+			rowError("%v", err)
+			continue
+		}
+
+		if rating := strings.TrimSpace(table.get(row, "rating")); rating != "" {
+			team.Rating, err = strconv.ParseFloat(rating, 64)
+			if err != nil || team.Rating < 0 {
+				rowError("invalid rating %q", rating)
+				continue
+			}
+		}
+
+		if fleet := strings.TrimSpace(table.get(row, "fleet")); fleet != "" {
+			fleetId, exists := fleetIds[strings.ToLower(fleet)]
+			if !exists {
+				rowError("unknown fleet %q", fleet)
+				continue
+			}
+			team.FleetID = fleetId
+		}
+
+		if team.SailNumber != "" {
+			key := team.FleetID + "\x00" + strings.ToUpper(team.SailNumber)
+			if first, exists := seen[key]; exists {
+				rowError("sail number %s is already used on row %d", team.SailNumber, first)
+				continue
+			}
+			seen[key] = line
+
+			taken, err := repo.SailNumberTaken(regattaId, team.FleetID, team.SailNumber, team.ID)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			if taken {
+				rowError("sail number %s is already used in this fleet", team.SailNumber)
+				continue
+			}
+		}
+
+		teams = append(teams, team)
+	}
+
+	if len(report.Errors) > 0 || report.DryRun {
+		writeImportReport(w, report)
+		return
+	}
+
+	// Every team is added or, when one fails, none are
+	err = repo.Transaction(func(store *repository.Repository) error {
+		for _, team := range teams {
+			if err := store.InsertTeam(team); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error importing teams: %v", err)
+		writeError(w, err)
+		return
+	}
+	report.Imported = len(teams)
+
+	log.Printf("Imported %d teams into RegattaID: %s", report.Imported, regattaId)
+	notifyTeamsChanged(regattaId)
+	writeImportReport(w, report)
+}
+
+// importResultsCSV records the finishing order of a race from a CSV file
+// with a sailNumber column and optional position, code and fleet columns.
+// Without a position column boats are placed in row order within their
+// fleet, coded boats taking no place. Imported results replace earlier results of the same
+// boats in the race. With ?dryRun=true the rows are only validated.
+func importResultsCSV(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	raceNumber, err := strconv.Atoi(r.URL.Query().Get("raceNumber"))
+	if err != nil || raceNumber < 1 {
+		httpError(w, "raceNumber query parameter must be 1 or greater", http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("importResultsCSV handler called - RegattaID: %s, race %d", regattaId, raceNumber)
+
+	table, err := readCSVUpload(r)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !table.has("sailnumber") {
+		httpError(w, "CSV header must include a sailNumber column", http.StatusBadRequest)
+		return
+	}
+
+	teams, err := regattaTeams(repo, regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	fleets, err := repo.ListFleets(regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	fleetIds := make(map[string]string)
+	for _, fleet := range fleets {
+		fleetIds[strings.ToLower(fleet.Name)] = fleet.ID
+	}
+
+	report := ImportReport{DryRun: r.URL.Query().Get("dryRun") == "true", Rows: len(table.rows), Errors: []RowError{}}
+	seen := make(map[string]int)
+	places := make(map[string]int)
+	var results []RaceResult
+	for i, row := range table.rows {
+		line := i + 2
+		rowError := func(format string, args ...interface{}) {
+			report.Errors = append(report.Errors, RowError{Row: line, Message: fmt.Sprintf(format, args...)})
+		}
+
+		code, err := scoring.ParseCode(table.get(row, "code"))
+		if err == nil {
+			err = scoring.ValidatePenalty(code, 0)
+		}
+		if err != nil {
+			rowError("%v", err)
+			continue
+		}
+
+		position := 0
+		if table.has("position") {
+			if value := strings.TrimSpace(table.get(row, "position")); value != "" {
+				position, err = strconv.Atoi(value)
+				if err != nil || position < 1 {
+					rowError("invalid position %q", value)
+					continue
+				}
+			}
+		}
+		if table.has("position") && position == 0 && code == "" {
+			rowError("position is required unless a code is given")
+			continue
+		}
+
+		sailNumber := strings.TrimSpace(table.get(row, "sailnumber"))
+		fleetName := strings.TrimSpace(table.get(row, "fleet"))
+		fleetId, fleetKnown := fleetIds[strings.ToLower(fleetName)]
+		if fleetName != "" && !fleetKnown {
+			rowError("unknown fleet %q", fleetName)
+			continue
+		}
+
+		var matches []Team
+		for _, team := range teams {
+			if strings.EqualFold(team.SailNumber, sailNumber) && (fleetName == "" || team.FleetID == fleetId) {
+				matches = append(matches, team)
+			}
+		}
+		if len(matches) == 0 {
+			rowError("no team with sail number %q", sailNumber)
+			continue
+		}
+		if len(matches) > 1 {
+			rowError("sail number %q is used in several fleets, add a fleet column", sailNumber)
+			continue
+		}
+		team := matches[0]
+
+		if first, exists := seen[team.ID]; exists {
+			rowError("sail number %s is already listed on row %d", sailNumber, first)
+			continue
+		}
+		seen[team.ID] = line
+
+		if err := repo.CheckRaceFinished(regattaId, team.FleetID, raceNumber); err != nil {
+			rowError("%v", err)
+			continue
+		}
+
+		// Each fleet sails its own race, so places count up per fleet
+		if !table.has("position") && code == "" {
+			places[team.FleetID]++
+			position = places[team.FleetID]
+		}
+
+		results = append(results, RaceResult{
+			RegattaID:  regattaId,
+			TeamID:     team.ID,
+			RaceNumber: raceNumber,
+			Position:   position,
+			Code:       string(code),
+		})
+	}
+
+	if len(report.Errors) > 0 || report.DryRun {
+		writeImportReport(w, report)
+		return
+	}
+
+	changes := newResultLog(r, regattaId)
+	err = changes.record(func(store *repository.Repository) error {
+		return saveRaceResults(store, changes, results)
+	})
+	if err != nil {
+		log.Printf("Error importing race results: %v", err)
+		writeError(w, err)
+		return
+	}
+	report.Imported = len(results)
+
+	log.Printf("Imported %d results for race %d of RegattaID: %s", report.Imported, raceNumber, regattaId)
+	writeImportReport(w, report)
+}
+
+// writeImportReport sends a report, with 422 Unprocessable Entity when any
+// row was rejected.
+func writeImportReport(w http.ResponseWriter, report ImportReport) {
+	w.Header().Set("Content-Type", "application/json")
+	if len(report.Errors) > 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// uploadReader returns the uploaded file of a request, sent either as the
+// request body or as the "file" field of a multipart form.
+func uploadReader(r *http.Request) (io.ReadCloser, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		return file, err
+	}
+	return r.Body, nil
+}
+
+// readCSVUpload parses an uploaded CSV file with a header row.
+func readCSVUpload(r *http.Request) (*csvTable, error) {
+	file, err := uploadReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV file is empty")
+	}
+
+	table := &csvTable{columns: make(map[string]int), rows: records[1:]}
+	for i, name := range records[0] {
+		table.columns[csvColumn(name)] = i
+	}
+	return table, nil
+}
+
+// csvColumn normalizes a header name, so "Sail Number" matches "sailNumber".
+func csvColumn(name string) string {
+	name = strings.TrimPrefix(name, "\ufeff")
+	name = strings.NewReplacer(" ", "", "_", "", "-", "").Replace(name)
+	return strings.ToLower(name)
+}
+
+func (t *csvTable) has(column string) bool {
+	_, exists := t.columns[column]
+	return exists
+}
+
+// get returns the value of a column in a row, or "" when the column or the
+// cell is missing.
+func (t *csvTable) get(row []string, column string) string {
+	i, exists := t.columns[column]
+	if !exists || i >= len(row) {
+		return ""
+	}
+	return row[i]
+}
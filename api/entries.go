@@ -0,0 +1,24 @@
+package main
+
+import (
+	"regatta-project/pkg/repository"
+)
+
+// EntryDetails describe the boat and people behind an entry, as published on
+// results and used to check eligibility. The sail number is unique within a
+// fleet.
+type EntryDetails = repository.EntryDetails
+
+// regattaTeams returns the teams of a regatta by ID.
+func regattaTeams(store *repository.Repository, regattaId string) (map[string]Team, error) {
+	list, err := store.ListTeams(regattaId)
+	if err != nil {
+		return nil, err
+	}
+
+	teams := make(map[string]Team, len(list))
+	for _, team := range list {
+		teams[team.ID] = team
+	}
+	return teams, nil
+}
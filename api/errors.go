@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"regatta-project/pkg/repository"
+)
+
+// Types
+
+// ErrorResponse is the body of every error the API returns.
+type ErrorResponse struct {
+	Error  string `json:"error"`
+	Status int    `json:"status"`
+}
+
+// internalErrorMessage replaces the message of a server error, whose
+// details are logged rather than shown to the client.
+const internalErrorMessage = "Internal server error"
+
+// httpError replies with an ErrorResponse in place of http.Error.
+func httpError(w http.ResponseWriter, message string, status int) {
+	if status >= http.StatusInternalServerError {
+		log.Printf("Internal error: %s", message)
+		message = internalErrorMessage
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message, Status: status})
+}
+
+// writeError replies with the status matching a repository error: 404 for a
+// missing record, 409 for a conflict, 400 for invalid input and 500 for a
+// failing database.
+func writeError(w http.ResponseWriter, err error) {
+	httpError(w, err.Error(), errorStatus(err))
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrValidation):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"regatta-project/pkg/repository"
+
+	"github.com/gorilla/mux"
+)
+
+// Types
+type Fleet = repository.Fleet
+
+func getRegattaFleets(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	fleets, err := repo.ListFleets(regattaId)
+	if err != nil {
+		log.Printf("Error fetching fleets: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fleets)
+}
+
+func addFleet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	var fleet Fleet
+	if err := json.NewDecoder(r.Body).Decode(&fleet); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fleet.RegattaID = regattaId
+	if err := repo.CreateFleet(&fleet); err != nil {
+		log.Printf("Error creating fleet: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fleet)
+}
+
+func updateFleet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+	fleetId := vars["fleetId"]
+
+	var fleet Fleet
+	if err := json.NewDecoder(r.Body).Decode(&fleet); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fleet.ID = fleetId
+	fleet.RegattaID = regattaId
+	if err := repo.UpdateFleet(&fleet); err != nil {
+		log.Printf("Error updating fleet: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fleet)
+}
+
+// deleteFleet removes a fleet that no team or race refers to.
+func deleteFleet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+	fleetId := vars["fleetId"]
+
+	if err := repo.DeleteFleet(regattaId, fleetId); err != nil {
+		log.Printf("Error deleting fleet: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
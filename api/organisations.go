@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"regatta-project/pkg/repository"
+
+	"github.com/gorilla/mux"
+)
+
+// Types
+
+type Organisation = repository.Organisation
+
+const organisationContextKey contextKey = "organisation"
+
+// scopeToOrganisation works out the organisation a request is scoped to and
+// hides the regattas and series of other organisations from it, so every
+// route under a regatta or series only reaches its own club's data.
+//
+// Signed in users are scoped to their organisation. The public and site
+// admins, who belong to none, may pick one with ?organisation= (its slug or
+// ID) and otherwise see every organisation.
+func scopeToOrganisation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		organisationId, err := organisationScope(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		if organisationId != "" {
+			vars := mux.Vars(r)
+			for _, id := range []string{vars["id"], vars["regattaId"]} {
+				if id == "" {
+					continue
+				}
+				owned, err := ownedBy(repo.RegattaOwner, id, organisationId)
+				if err != nil {
+					writeError(w, err)
+					return
+				}
+				if !owned {
+					httpError(w, "Regatta not found", http.StatusNotFound)
+					return
+				}
+			}
+			if id := vars["seriesId"]; id != "" {
+				owned, err := ownedBy(repo.SeriesOwner, id, organisationId)
+				if err != nil {
+					writeError(w, err)
+					return
+				}
+				if !owned {
+					httpError(w, "Series not found", http.StatusNotFound)
+					return
+				}
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), organisationContextKey, organisationId)))
+	})
+}
+
+// organisationScope resolves the organisation of a request, empty when it
+// is not scoped to one.
+func organisationScope(r *http.Request) (string, error) {
+	if user := currentUser(r); user != nil && user.OrganisationID != "" {
+		return user.OrganisationID, nil
+	}
+	requested := r.URL.Query().Get("organisation")
+	if requested == "" {
+		return "", nil
+	}
+	organisation, err := repo.GetOrganisation(requested)
+	if err != nil {
+		return "", err
+	}
+	return organisation.ID, nil
+}
+
+// requestOrganisation returns the organisation scope set by
+// scopeToOrganisation.
+func requestOrganisation(r *http.Request) string {
+	organisationId, _ := r.Context().Value(organisationContextKey).(string)
+	return organisationId
+}
+
+// ownedBy reports whether a regatta or series, whose owner is read by
+// owner, may be reached from an organisation. One that does not exist is
+// let through so its handler can report it missing as before.
+func ownedBy(owner func(id string) (string, error), id, organisationId string) (bool, error) {
+	ownerId, err := owner(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return ownerId == organisationId, nil
+}
+
+// organisationForCreate picks the organisation owning a new regatta or
+// series: the request's scope, or else the one named in the body. Naming
+// an organisation that does not exist is invalid input.
+func organisationForCreate(r *http.Request, requested string) (string, error) {
+	if organisationId := requestOrganisation(r); organisationId != "" {
+		return organisationId, nil
+	}
+	if requested == "" {
+		return "", nil
+	}
+	organisation, err := repo.GetOrganisation(requested)
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", repository.Validation("%v", err)
+	}
+	if err != nil {
+		return "", err
+	}
+	return organisation.ID, nil
+}
+
+func getOrganisations(w http.ResponseWriter, r *http.Request) {
+	organisations, err := repo.ListOrganisations()
+	if err != nil {
+		log.Printf("Error fetching organisations: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(organisations)
+}
+
+// getOrganisation serves an organisation by its ID or slug.
+func getOrganisation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	organisation, err := repo.GetOrganisation(vars["organisationId"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(organisation)
+}
+
+// createOrganisation adds a club. Only a site admin, who belongs to no
+// organisation, may do this.
+func createOrganisation(w http.ResponseWriter, r *http.Request) {
+	if currentUser(r).OrganisationID != "" {
+		httpError(w, "Only a site admin can create organisations", http.StatusForbidden)
+		return
+	}
+
+	var organisation Organisation
+	if err := json.NewDecoder(r.Body).Decode(&organisation); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := repo.CreateOrganisation(&organisation); err != nil {
+		log.Printf("Error creating organisation: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	log.Printf("Created organisation %s (%s)", organisation.Name, organisation.Slug)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(organisation)
+}
+
+// updateOrganisation renames an organisation or changes its slug. Club
+// admins may only change their own.
+func updateOrganisation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	organisation, err := repo.GetOrganisation(vars["organisationId"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if scope := requestOrganisation(r); scope != "" && scope != organisation.ID {
+		httpError(w, "Organisation not found", http.StatusNotFound)
+		return
+	}
+
+	id, createdAt := organisation.ID, organisation.CreatedAt
+	if err := json.NewDecoder(r.Body).Decode(&organisation); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	organisation.ID, organisation.CreatedAt = id, createdAt
+	if err := repo.UpdateOrganisation(&organisation); err != nil {
+		log.Printf("Error updating organisation: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(organisation)
+}
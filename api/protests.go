@@ -0,0 +1,340 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"regatta-project/pkg/repository"
+	"regatta-project/pkg/scoring"
+
+	"github.com/gorilla/mux"
+)
+
+// Protest statuses
+const (
+	ProtestLodged    = repository.ProtestLodged
+	ProtestWithdrawn = repository.ProtestWithdrawn
+	ProtestDecided   = repository.ProtestDecided
+)
+
+// Types
+
+type Protest = repository.Protest
+type ProtestChange = repository.ProtestChange
+
+// ProtestPenalty is a scoring outcome of a decision for one boat in the
+// protest's race: DSQ, DNE, DPI with a penalty percentage, RDG with its
+// redress mode, or an empty code to reinstate a boat's finishing place.
+type ProtestPenalty struct {
+	TeamID        string  `json:"teamId"`
+	Code          string  `json:"code"`
+	Penalty       float64 `json:"penalty,omitempty"`
+	RedressMode   string  `json:"redressMode,omitempty"`
+	RedressPoints float64 `json:"redressPoints,omitempty"`
+}
+
+// ProtestDecision is the jury's decision on a protest and the scores it
+// gives.
+type ProtestDecision struct {
+	Decision  string           `json:"decision"`
+	Penalties []ProtestPenalty `json:"penalties"`
+}
+
+// Kinds of protest change
+const (
+	changeDecision = repository.ChangeDecision
+	changeReversal = repository.ChangeReversal
+)
+
+func getRegattaProtests(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	protests, err := repo.ListProtests(regattaId)
+	if err != nil {
+		log.Printf("Error fetching protests: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(protests)
+}
+
+func getProtest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	protest, err := repo.GetProtest(vars["regattaId"], vars["protestId"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(protest)
+}
+
+// lodgeProtest records a new protest against a race of a regatta.
+func lodgeProtest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	log.Printf("Received request to lodge a protest in regatta %s", regattaId)
+
+	var protest Protest
+	if err := json.NewDecoder(r.Body).Decode(&protest); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	protest.RegattaID = regattaId
+	if err := repo.CreateProtest(&protest); err != nil {
+		log.Printf("Error lodging protest: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(protest)
+}
+
+// updateProtest edits the details of a protest that has not been decided,
+// or withdraws it with status WITHDRAWN.
+func updateProtest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+	protestId := vars["protestId"]
+
+	current, err := repo.GetProtest(regattaId, protestId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if current.Status == ProtestDecided {
+		httpError(w, "Protest has been decided; post a new decision instead", http.StatusConflict)
+		return
+	}
+
+	protest := current
+	if err := json.NewDecoder(r.Body).Decode(&protest); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	protest.ID = current.ID
+	protest.RegattaID = current.RegattaID
+	protest.Decision = current.Decision
+	protest.LodgedAt = current.LodgedAt
+	protest.DecidedAt = current.DecidedAt
+	protest.Changes = current.Changes
+
+	protest.Status = strings.ToUpper(strings.TrimSpace(protest.Status))
+	if protest.Status != ProtestLodged && protest.Status != ProtestWithdrawn {
+		httpError(w, fmt.Sprintf("status must be %s or %s", ProtestLodged, ProtestWithdrawn), http.StatusBadRequest)
+		return
+	}
+	if err := repo.UpdateProtest(&protest); err != nil {
+		log.Printf("Error updating protest: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(protest)
+}
+
+// decideProtest records the jury's decision and applies its penalties to the
+// race results of the protest's race, then rescores the race. A protest that
+// was already decided may be decided again: the changes of the earlier
+// decision are reversed first.
+func decideProtest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+	protestId := vars["protestId"]
+
+	log.Printf("Received decision for protest %s", protestId)
+
+	protest, err := repo.GetProtest(regattaId, protestId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if protest.Status == ProtestWithdrawn {
+		httpError(w, "Protest has been withdrawn", http.StatusConflict)
+		return
+	}
+
+	var decision ProtestDecision
+	if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	decision.Decision = strings.TrimSpace(decision.Decision)
+	if decision.Decision == "" {
+		httpError(w, "decision is required", http.StatusBadRequest)
+		return
+	}
+
+	// Results as they will be once the earlier decision is reversed
+	results, err := protestRaceResults(regattaId, protest.RaceNumber)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var reversals []ProtestChange
+	for i := len(protest.Changes) - 1; i >= 0; i-- {
+		change := protest.Changes[i]
+		if change.Kind != changeDecision || change.Reversed {
+			continue
+		}
+		result, exists := results[change.TeamID]
+		if !exists || result.ID != change.ResultID {
+			continue
+		}
+		reversals = append(reversals, ProtestChange{
+			ResultID:   change.ResultID,
+			TeamID:     change.TeamID,
+			RaceNumber: change.RaceNumber,
+			Kind:       changeReversal,
+			OldCode:    result.Code,
+			OldPenalty: result.Penalty,
+			NewCode:    change.OldCode,
+			NewPenalty: change.OldPenalty,
+
+			OldRedressMode:   result.RedressMode,
+			OldRedressPoints: result.RedressPoints,
+			NewRedressMode:   change.OldRedressMode,
+			NewRedressPoints: change.OldRedressPoints,
+		})
+		result.Code = change.OldCode
+		result.Penalty = change.OldPenalty
+		result.RedressMode = change.OldRedressMode
+		result.RedressPoints = change.OldRedressPoints
+		results[change.TeamID] = result
+	}
+
+	seen := make(map[string]bool)
+	var changes []ProtestChange
+	for _, penalty := range decision.Penalties {
+		code, err := scoring.ParseCode(penalty.Code)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := scoring.ValidatePenalty(code, penalty.Penalty); err != nil {
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		redress, err := scoring.ParseRedress(code, penalty.RedressMode, penalty.RedressPoints)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if seen[penalty.TeamID] {
+			httpError(w, fmt.Sprintf("team %s is given more than one score", penalty.TeamID), http.StatusBadRequest)
+			return
+		}
+		seen[penalty.TeamID] = true
+
+		result, exists := results[penalty.TeamID]
+		if !exists {
+			httpError(w, fmt.Sprintf("team %s has no result in race %d", penalty.TeamID, protest.RaceNumber), http.StatusConflict)
+			return
+		}
+		if result.Position == 0 && code.KeepsPlace() {
+			httpError(w, fmt.Sprintf("team %s has no finishing place in race %d to score %s", penalty.TeamID, protest.RaceNumber, penaltyLabel(code)), http.StatusConflict)
+			return
+		}
+
+		changes = append(changes, ProtestChange{
+			ResultID:   result.ID,
+			TeamID:     penalty.TeamID,
+			RaceNumber: protest.RaceNumber,
+			Kind:       changeDecision,
+			OldCode:    result.Code,
+			OldPenalty: result.Penalty,
+			NewCode:    string(code),
+			NewPenalty: penalty.Penalty,
+
+			OldRedressMode:   result.RedressMode,
+			OldRedressPoints: result.RedressPoints,
+			NewRedressMode:   string(redress),
+			NewRedressPoints: penalty.RedressPoints,
+		})
+	}
+
+	now := time.Now().UTC()
+	history := newResultLog(r, regattaId)
+	if history.reason == "" {
+		history.reason = decision.Decision
+	}
+	err = history.record(func(store *repository.Repository) error {
+		if err := store.ReverseDecisions(protest.ID); err != nil {
+			return err
+		}
+
+		amended := make(map[string]bool)
+		for _, change := range append(reversals, changes...) {
+			if !amended[change.ResultID] {
+				before, err := store.GetResult(change.ResultID)
+				if err != nil {
+					return err
+				}
+				history.amended(ResultDecision, before)
+				amended[change.ResultID] = true
+			}
+			if err := store.ApplyProtestChange(protest.ID, change, now); err != nil {
+				return err
+			}
+		}
+
+		if err := store.DecideProtest(protest.ID, decision.Decision, now); err != nil {
+			return err
+		}
+		return rescoreRace(store, regattaId, protest.RaceNumber)
+	})
+	if err != nil {
+		log.Printf("Error recording protest decision: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	protest, err = repo.GetProtest(regattaId, protestId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(protest)
+}
+
+// penaltyLabel names a decision's code in messages, an empty code being a
+// reinstated place.
+func penaltyLabel(code scoring.Code) string {
+	if code == "" {
+		return "a place"
+	}
+	return string(code)
+}
+
+// protestRaceResults reads the results of a race by team.
+func protestRaceResults(regattaId string, raceNumber int) (map[string]RaceResult, error) {
+	stored, err := repo.RaceResults(regattaId, raceNumber, "")
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]RaceResult, len(stored))
+	for _, result := range stored {
+		results[result.TeamID] = result
+	}
+	return results, nil
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"regatta-project/pkg/racestatus"
+	"regatta-project/pkg/repository"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Publication statuses. A provisional publication is under protest while
+// protests about it lodged within its protest time limit are undecided, and
+// becomes final when the limit has passed with none open, or when published
+// as final. Protests lodged later do not reopen it.
+const (
+	PublicationProvisional  = "PROVISIONAL"
+	PublicationUnderProtest = "UNDER_PROTEST"
+	PublicationFinal        = "FINAL"
+)
+
+// publicationLabels describe the publication statuses on results sheets.
+var publicationLabels = map[string]string{
+	PublicationProvisional:  "Provisional results",
+	PublicationUnderProtest: "Under protest",
+	PublicationFinal:        "Final results",
+}
+
+// defaultProtestTimeLimit applies to regattas without a protest time limit.
+const defaultProtestTimeLimit = 60 * time.Minute
+
+// Types
+
+// Publication is a snapshot of results posted on the official notice board:
+// the standings of the regatta, or the scored results of one race when
+// RaceNumber is set.
+type Publication struct {
+	repository.Publication
+	Standings []FleetStandings `json:"standings,omitempty"`
+	Results   []RaceResult     `json:"results,omitempty"`
+}
+
+// PublishedResults is what the public may see of a regatta: its latest
+// published standings and the latest publication of each race.
+type PublishedResults struct {
+	Regatta *Publication  `json:"regatta"`
+	Races   []Publication `json:"races"`
+}
+
+// publishRegatta posts the current standings of a regatta. With
+// {"final": true} they are published as final, which needs every protest
+// to be decided or withdrawn.
+func publishRegatta(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	log.Printf("Received request to publish standings of regatta %s", regattaId)
+
+	final, err := readPublishRequest(r)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	standings, err := computeStandings(repo, regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	publication := Publication{Standings: standings}
+	publication.RegattaID = regattaId
+	publish(w, r, publication, final)
+}
+
+// publishRace posts the scored results of a finished race.
+func publishRace(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+	raceId := vars["raceId"]
+
+	log.Printf("Received request to publish results of race %s", raceId)
+
+	final, err := readPublishRequest(r)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	race, err := repo.GetRace(regattaId, raceId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if race.Status != racestatus.Finished {
+		httpError(w, "Only a finished race can be published", http.StatusConflict)
+		return
+	}
+
+	standings, err := computeStandings(repo, regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	publication := Publication{Results: []RaceResult{}}
+	publication.RegattaID = regattaId
+	publication.RaceNumber = race.RaceNumber
+	for _, group := range standings {
+		if race.FleetID != "" && group.FleetID != race.FleetID {
+			continue
+		}
+		for _, standing := range group.Standings {
+			for _, result := range standing.Results {
+				if result.RaceNumber == race.RaceNumber {
+					publication.Results = append(publication.Results, result)
+				}
+			}
+		}
+	}
+	sort.SliceStable(publication.Results, func(i, j int) bool {
+		return publication.Results[i].Points < publication.Results[j].Points
+	})
+
+	publish(w, r, publication, final)
+}
+
+// readPublishRequest reads the optional body of a publish request.
+func readPublishRequest(r *http.Request) (bool, error) {
+	var request struct {
+		Final bool `json:"final"`
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return false, err
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &request); err != nil {
+			return false, err
+		}
+	}
+	return request.Final, nil
+}
+
+// publish stores a publication and starts its protest time limit, or marks
+// it final.
+func publish(w http.ResponseWriter, r *http.Request, publication Publication, final bool) {
+	open, err := repo.OpenProtests(publication.RegattaID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	publication.ID = uuid.New().String()
+	publication.PublishedBy = changedBy(r)
+	publication.PublishedAt = time.Now().UTC()
+	publication.Status = PublicationProvisional
+
+	if final {
+		if publication.protestsOpen(open) {
+			httpError(w, "Undecided protests remain; results cannot be published as final", http.StatusConflict)
+			return
+		}
+		publication.Status = PublicationFinal
+	} else {
+		regatta, err := repo.GetRegatta(publication.RegattaID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		limit := defaultProtestTimeLimit
+		if regatta.ProtestTimeLimit > 0 {
+			limit = time.Duration(regatta.ProtestTimeLimit) * time.Minute
+		}
+		deadline := publication.PublishedAt.Add(limit)
+		publication.ProtestDeadline = &deadline
+	}
+
+	content, err := json.Marshal(publication.content())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	publication.Content = string(content)
+	if err := repo.InsertPublication(publication.Publication); err != nil {
+		log.Printf("Error publishing results: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	log.Printf("Published %s results of regatta %s (race %d)", publication.Status, publication.RegattaID, publication.RaceNumber)
+
+	publication.Status = publication.currentStatus(open, publication.PublishedAt)
+	standingsStream.broadcast(StandingsEvent{Type: StreamPublished, RegattaID: publication.RegattaID, Publication: &publication})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(publication)
+}
+
+// getPublishedResults serves the latest publication of a regatta's
+// standings and of each of its races, with their current status. Public
+// views show these rather than the working results.
+func getPublishedResults(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	publications, err := loadPublications(regattaId, true)
+	if err != nil {
+		log.Printf("Error fetching publications: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	published := PublishedResults{Races: []Publication{}}
+	latest := make(map[int]bool)
+	for i := len(publications) - 1; i >= 0; i-- {
+		publication := publications[i]
+		if latest[publication.RaceNumber] {
+			continue
+		}
+		latest[publication.RaceNumber] = true
+		if publication.RaceNumber == 0 {
+			published.Regatta = &publication
+		} else {
+			published.Races = append(published.Races, publication)
+		}
+	}
+	sort.Slice(published.Races, func(i, j int) bool {
+		return published.Races[i].RaceNumber < published.Races[j].RaceNumber
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(published)
+}
+
+// latestStandings returns the latest publication of a regatta's standings,
+// or nil when they have not been published.
+func latestStandings(regattaId string) (*Publication, error) {
+	publications, err := loadPublications(regattaId, true)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(publications) - 1; i >= 0; i-- {
+		if publications[i].RaceNumber == 0 {
+			return &publications[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// viewableStandings returns the standings of a regatta the user of a
+// request may see: the working standings for staff, and for the public the
+// latest published standings with their publication, or none before the
+// first is published. It returns a repository.ErrNotFound error when the
+// regatta does not exist.
+func viewableStandings(r *http.Request, regattaId string) ([]FleetStandings, *Publication, error) {
+	if seesWorkingResults(r) {
+		standings, err := computeStandings(repo, regattaId)
+		return standings, nil, err
+	}
+
+	if _, err := repo.GetRegatta(regattaId); err != nil {
+		return nil, nil, err
+	}
+	publication, err := latestStandings(regattaId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if publication == nil || publication.Standings == nil {
+		return []FleetStandings{}, publication, nil
+	}
+	return publication.Standings, publication, nil
+}
+
+// getPublications lists every publication of a regatta, oldest first,
+// without their contents.
+func getPublications(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	publications, err := loadPublications(regattaId, false)
+	if err != nil {
+		log.Printf("Error fetching publications: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(publications)
+}
+
+// loadPublications reads the publications of a regatta in publishing order
+// with their current status, and their contents when withContent is set.
+func loadPublications(regattaId string, withContent bool) ([]Publication, error) {
+	open, err := repo.OpenProtests(regattaId)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := repo.ListPublications(regattaId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	publications := make([]Publication, 0, len(stored))
+	for _, row := range stored {
+		publication := Publication{Publication: row}
+		if withContent {
+			var err error
+			if publication.RaceNumber == 0 {
+				err = json.Unmarshal([]byte(row.Content), &publication.Standings)
+			} else {
+				err = json.Unmarshal([]byte(row.Content), &publication.Results)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		publication.Status = publication.currentStatus(open, now)
+		publications = append(publications, publication)
+	}
+	return publications, nil
+}
+
+// content returns what a publication posts: standings or race results.
+func (p Publication) content() any {
+	if p.RaceNumber == 0 {
+		return p.Standings
+	}
+	return p.Results
+}
+
+// protestsOpen reports whether undecided protests concern the publication:
+// those about its race, or about any race for the regatta standings, lodged
+// before its protest deadline when it has one.
+func (p Publication) protestsOpen(open map[int][]time.Time) bool {
+	for raceNumber, lodged := range open {
+		if p.RaceNumber != 0 && raceNumber != p.RaceNumber {
+			continue
+		}
+		for _, at := range lodged {
+			if p.ProtestDeadline == nil || at.Before(*p.ProtestDeadline) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// currentStatus works out the status of a stored publication at a time.
+func (p Publication) currentStatus(open map[int][]time.Time, now time.Time) string {
+	if p.Status == PublicationFinal {
+		return PublicationFinal
+	}
+	if p.protestsOpen(open) {
+		return PublicationUnderProtest
+	}
+	if p.ProtestDeadline != nil && now.Before(*p.ProtestDeadline) {
+		return PublicationProvisional
+	}
+	return PublicationFinal
+}
@@ -0,0 +1,548 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"regatta-project/pkg/racestatus"
+	"regatta-project/pkg/repository"
+	"regatta-project/pkg/scoring"
+
+	"github.com/gorilla/mux"
+)
+
+// Types
+type Race = repository.Race
+type Finish = repository.Finish
+
+func createRace(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Received request to create a race")
+
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	var race Race
+	if err := json.NewDecoder(r.Body).Decode(&race); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	race.RegattaID = regattaId
+	if err := repo.CreateRace(&race); err != nil {
+		log.Printf("Error creating race: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	log.Printf("Successfully created race: %+v", race)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(race)
+}
+
+func getRegattaRaces(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	races, err := repo.ListRaces(regattaId)
+	if err != nil {
+		log.Printf("Error fetching races: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(races)
+}
+
+func getRace(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+	raceId := vars["raceId"]
+
+	race, err := repo.GetRace(regattaId, raceId)
+	if err != nil {
+		log.Printf("Error fetching race: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(race)
+}
+
+// updateRace changes the number, start time or distance of a race. Fields
+// missing from the body keep their values; the fleet is fixed once the race
+// is created and the status only changes through setRaceStatus.
+func updateRace(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+	raceId := vars["raceId"]
+
+	log.Printf("Received request to update race with ID: %s", raceId)
+
+	current, err := repo.GetRace(regattaId, raceId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	race := current
+	if err := json.NewDecoder(r.Body).Decode(&race); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	race.ID = current.ID
+	race.RegattaID = current.RegattaID
+	race.FleetID = current.FleetID
+	race.Status = current.Status
+	race.EndTime = current.EndTime
+
+	// A finished race is rescored from its finishes with the new settings
+	var finishes []Finish
+	if race.Status == racestatus.Finished {
+		finishes, err = repo.ListFinishes(race.ID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if len(finishes) > 0 {
+			finishes, err = deriveFinishes(race, finishes)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	changes := newResultLog(r, regattaId)
+	err = changes.record(func(store *repository.Repository) error {
+		if err := store.UpdateRace(&race); err != nil {
+			return err
+		}
+
+		if race.RaceNumber != current.RaceNumber {
+			renumbered, err := store.RenumberResults(regattaId, current.RaceNumber, race.RaceNumber, race.FleetID)
+			if err != nil {
+				return err
+			}
+			for _, result := range renumbered {
+				changes.amended(ResultAmended, result)
+			}
+		}
+
+		if len(finishes) > 0 {
+			return storeFinishResults(store, changes, race, finishes)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error updating race: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	log.Printf("Successfully updated race with ID: %s", raceId)
+
+	race, err = repo.GetRace(regattaId, raceId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(race)
+}
+
+// deleteRace removes a race together with its finishes and results.
+func deleteRace(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+	raceId := vars["raceId"]
+
+	log.Printf("Received request to delete race with ID: %s", raceId)
+
+	race, err := repo.GetRace(regattaId, raceId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	changes := newResultLog(r, regattaId)
+	err = changes.record(func(store *repository.Repository) error {
+		deleted, err := store.DeleteRaceResults(regattaId, race.RaceNumber, race.FleetID)
+		if err != nil {
+			return err
+		}
+		changes.deleted(deleted)
+
+		return store.DeleteRace(race.ID)
+	})
+	if err != nil {
+		log.Printf("Error deleting race: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	log.Printf("Successfully deleted race with ID: %s", raceId)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setRaceStatus moves a race through its lifecycle. Starting a race records
+// the start time, finishing it derives results from the recorded finishes,
+// abandoning it removes its results and rescheduling an abandoned race
+// clears it for a resail.
+func setRaceStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+	raceId := vars["raceId"]
+
+	var requestData struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status, err := racestatus.Parse(requestData.Status)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	race, err := repo.GetRace(regattaId, raceId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := racestatus.CheckTransition(race.Status, status); err != nil {
+		httpError(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	log.Printf("Race %s moving from %s to %s", race.ID, race.Status, status)
+
+	// Finishing a race derives its results from the recorded finishes,
+	// rejected untouched when the race cannot be scored
+	var finishes []Finish
+	if status == racestatus.Finished {
+		finishes, err = repo.ListFinishes(race.ID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if len(finishes) > 0 {
+			finishes, err = deriveFinishes(race, finishes)
+			if err != nil {
+				httpError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	changes := newResultLog(r, regattaId)
+	err = changes.record(func(store *repository.Repository) error {
+		var err error
+		switch status {
+		case racestatus.Racing:
+			if race.StartTime == nil {
+				now := time.Now().UTC()
+				race.StartTime = &now
+			}
+			err = store.SetRaceTimes(race.ID, race.StartTime, race.EndTime)
+
+		case racestatus.Finished:
+			if len(finishes) == 0 {
+				// Results will be entered by position
+				now := time.Now().UTC()
+				err = store.SetRaceTimes(race.ID, race.StartTime, &now)
+				break
+			}
+			err = storeFinishResults(store, changes, race, finishes)
+
+		case racestatus.Abandoned:
+			var deleted []RaceResult
+			deleted, err = store.DeleteRaceResults(regattaId, race.RaceNumber, race.FleetID)
+			changes.deleted(deleted)
+
+		case racestatus.Scheduled:
+			if race.Status == racestatus.Abandoned {
+				if err = store.DeleteFinishes(race.ID); err != nil {
+					break
+				}
+				err = store.SetRaceTimes(race.ID, nil, nil)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		return store.SetRaceStatus(race.ID, status)
+	})
+	if err != nil {
+		log.Printf("Error changing race status: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	race, err = repo.GetRace(regattaId, raceId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(race)
+}
+
+func getRaceFinishes(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+	raceId := vars["raceId"]
+
+	race, err := repo.GetRace(regattaId, raceId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	finishes, err := repo.ListFinishes(race.ID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	finishes, err = deriveFinishes(race, finishes)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(finishes)
+}
+
+// recordFinishes stores finish times for a race and re-derives the race
+// results from them. Recording a boat again replaces its earlier finish.
+func recordFinishes(w http.ResponseWriter, r *http.Request) {
+	log.Printf("recordFinishes handler called - Method: %s, URL: %s", r.Method, r.URL.Path)
+
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+	raceId := vars["raceId"]
+
+	var finishes []Finish
+	if err := json.NewDecoder(r.Body).Decode(&finishes); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	race, err := repo.GetRace(regattaId, raceId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if race.Status != racestatus.Racing && race.Status != racestatus.Finished {
+		httpError(w, "Finishes can only be recorded while a race is racing or finished", http.StatusConflict)
+		return
+	}
+	if race.StartTime == nil {
+		httpError(w, "Race has no start time", http.StatusConflict)
+		return
+	}
+
+	entries, err := repo.Entries(regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Validate every finish before anything is written
+	for i := range finishes {
+		finish := &finishes[i]
+		entry, exists := entries[finish.TeamID]
+		if !exists {
+			httpError(w, "Team not found or doesn't belong to this regatta: "+finish.TeamID, http.StatusBadRequest)
+			return
+		}
+		if race.FleetID != "" && entry.FleetID != race.FleetID {
+			httpError(w, "Team does not sail in this race's fleet: "+finish.TeamID, http.StatusBadRequest)
+			return
+		}
+
+		code, err := scoring.ParseCode(finish.Code)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		finish.Code = string(code)
+
+		if finish.FinishTime == nil && code == "" {
+			httpError(w, "finishTime is required unless a code is given", http.StatusBadRequest)
+			return
+		}
+		if finish.FinishTime != nil && !finish.FinishTime.After(*race.StartTime) {
+			httpError(w, "finishTime must be after the race start time", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Derive the new finishing order before writing so a race that cannot be
+	// scored (e.g. missing distance or ratings) is rejected untouched
+	recorded, err := repo.ListFinishes(race.ID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	merged := make([]Finish, 0, len(recorded)+len(finishes))
+	replaced := make(map[string]bool)
+	for _, finish := range finishes {
+		replaced[finish.TeamID] = true
+	}
+	for _, finish := range recorded {
+		if !replaced[finish.TeamID] {
+			merged = append(merged, finish)
+		}
+	}
+	merged = append(merged, finishes...)
+
+	derived, err := deriveFinishes(race, merged)
+	if err != nil {
+		log.Printf("Error deriving finishing order: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	changes := newResultLog(r, regattaId)
+	err = changes.record(func(store *repository.Repository) error {
+		for _, finish := range finishes {
+			if err := store.RecordFinish(race.ID, finish); err != nil {
+				return err
+			}
+			log.Printf("Finish recorded - RaceID: %s, TeamID: %s", race.ID, finish.TeamID)
+		}
+
+		// Results of a race still racing are stored once it finishes
+		if race.Status == racestatus.Finished {
+			return storeFinishResults(store, changes, race, derived)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error recording finishes: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(derived)
+}
+
+// deriveFinishes computes elapsed times from the race start, then corrected
+// times and finishing positions under the regatta's handicap system.
+func deriveFinishes(race Race, finishes []Finish) ([]Finish, error) {
+	regatta, err := repo.GetRegatta(race.RegattaID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := repo.Entries(race.RegattaID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]RaceResult, len(finishes))
+	for i, finish := range finishes {
+		results[i] = RaceResult{TeamID: finish.TeamID, Code: finish.Code}
+		if finish.FinishTime != nil && race.StartTime != nil {
+			results[i].ElapsedTime = finish.FinishTime.Sub(*race.StartTime).Seconds()
+		}
+	}
+
+	if err := derivePositions(regatta.HandicapSystem, entries, race.Distance, results); err != nil {
+		return nil, err
+	}
+
+	derived := make([]Finish, len(finishes))
+	for i, finish := range finishes {
+		finish.ElapsedTime = results[i].ElapsedTime
+		finish.CorrectedTime = results[i].CorrectedTime
+		finish.Position = results[i].Position
+		derived[i] = finish
+	}
+	return derived, nil
+}
+
+// storeFinishResults updates the race results of a race in store to the
+// ones derived from its finishes, recording the changes, and marks the race
+// end at the last finish. Results keep their IDs and scoring penalties, and
+// saveRaceResults keeps a protest decision in force. Boats without a finish
+// keep a coded or decided result, out of the finishing order; their other
+// results are removed.
+func storeFinishResults(store *repository.Repository, changes *resultLog, race Race, finishes []Finish) error {
+	stored, err := store.RaceResults(race.RegattaID, race.RaceNumber, race.FleetID)
+	if err != nil {
+		return err
+	}
+	decided, err := store.DecidedResults(race.RegattaID, race.RaceNumber)
+	if err != nil {
+		return err
+	}
+	previous := make(map[string]RaceResult)
+	for _, result := range stored {
+		previous[result.TeamID] = result
+	}
+
+	var endTime *time.Time
+	results := make([]RaceResult, len(finishes))
+	for i, finish := range finishes {
+		result := RaceResult{
+			RegattaID:     race.RegattaID,
+			TeamID:        finish.TeamID,
+			RaceNumber:    race.RaceNumber,
+			Position:      finish.Position,
+			Code:          finish.Code,
+			ElapsedTime:   finish.ElapsedTime,
+			CorrectedTime: finish.CorrectedTime,
+		}
+		if before, exists := previous[finish.TeamID]; exists {
+			result.Penalties = before.Penalties
+			delete(previous, finish.TeamID)
+		}
+		results[i] = result
+
+		if finish.FinishTime != nil && (endTime == nil || finish.FinishTime.After(*endTime)) {
+			endTime = finish.FinishTime
+		}
+	}
+
+	for _, result := range previous {
+		if result.Code != "" || decided[result.ID] {
+			if result.Position != 0 || result.ElapsedTime != 0 || result.CorrectedTime != 0 {
+				result.Position, result.ElapsedTime, result.CorrectedTime = 0, 0, 0
+				results = append(results, result)
+			}
+			continue
+		}
+		deleted, err := store.DeleteResult(result.ID)
+		if err != nil {
+			return err
+		}
+		changes.deleted([]RaceResult{deleted})
+	}
+
+	if err := saveRaceResults(store, changes, results); err != nil {
+		return err
+	}
+
+	return store.SetRaceTimes(race.ID, race.StartTime, endTime)
+}
@@ -1,560 +1,827 @@
-package main
-
-import (
-	"encoding/json"
-	"io"
-	"log"
-	"net/http"
-	"os"
-
-	"regatta-project/pkg/db"
-
-	"github.com/google/uuid"
-	"github.com/mattn/go-sqlite3"
-
-	"github.com/gorilla/mux"
-)
-
-// Define the base URL for the API
-var baseURL = os.Getenv("BASE_URL")
-
-// Types
-type Regatta struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	StartDate string `json:"startDate"`
-	EndDate   string `json:"endDate"`
-	Location  string `json:"location"`
-	Status    string `json:"status"`
-}
-
-type Team struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	RegattaID string `json:"regattaId"`
-}
-
-type RaceResult struct {
-	ID         string `json:"id"`
-	RegattaID  string `json:"regattaId"`
-	TeamID     string `json:"teamId"`
-	RaceNumber int    `json:"raceNumber"`
-	Position   int    `json:"position"`
-	Points     int    `json:"points"`
-}
-
-type RaceScores struct {
-	RaceNumber int          `json:"raceNumber"`
-	Scores     []RaceResult `json:"scores"`
-}
-
-type TeamStanding struct {
-	TeamID      string       `json:"teamId"`
-	TeamName    string       `json:"name"`
-	TotalPoints int          `json:"totalPoints"`
-	Results     []RaceResult `json:"results"`
-}
-
-func main() {
-	// Initialize database
-	if err := db.InitDB(); err != nil {
-		log.Fatal(err)
-	}
-	defer db.DB.Close()
-
-	router := mux.NewRouter()
-
-	// Enable CORS
-	router.Use(corsMiddleware)
-
-	// API routes
-	router.HandleFunc("/api/regattas", createRegatta).Methods("POST", "OPTIONS")
-	router.HandleFunc("/api/regattas", getAllRegattas).Methods("GET", "OPTIONS")
-	router.HandleFunc("/api/regattas/{id}", getRegatta).Methods("GET", "OPTIONS")
-	router.HandleFunc("/api/regattas/{id}", updateRegatta).Methods("PUT", "OPTIONS")
-	router.HandleFunc("/api/regattas/{id}", deleteRegatta).Methods("DELETE", "OPTIONS")
-	router.HandleFunc("/api/regattas/{regattaId}/teams", getRegattaTeams).Methods("GET", "OPTIONS")
-	router.HandleFunc("/api/regattas/{regattaId}/teams", addTeam).Methods("POST", "OPTIONS")
-	router.HandleFunc("/api/regattas/{regattaId}/results", addRaceResults).Methods("POST", "OPTIONS")
-	router.HandleFunc("/api/regattas/{regattaId}/standings", getRegattaStandings).Methods("GET", "OPTIONS")
-	router.HandleFunc("/api/regattas/{regattaId}/results", clearRegattaResults).Methods("DELETE", "OPTIONS")
-	router.HandleFunc("/api/dashboard/stats", getDashboardStats).Methods("GET", "OPTIONS")
-	router.HandleFunc("/api/regattas/{regattaId}/teams/{teamId}", deleteTeam).Methods("DELETE", "OPTIONS")
-	router.HandleFunc("/api/regattas/{regattaId}/teams/{teamId}", updateTeam).Methods("PUT", "OPTIONS")
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8081"
-	}
-
-	// Log the server starting address
-	if port == "8081" { // Default port, log with baseURL and port
-		log.Printf("API Server starting on %s:%s", baseURL, port)
-	} else { // Custom port, log with baseURL only
-		log.Printf("API Server starting on %s", baseURL)
-	}
-
-	// Combine baseURL and port for ListenAndServe
-	address := baseURL
-	if port == "8081" {
-		address += ":" + port
-	}
-
-	if err := http.ListenAndServe(address, router); err != nil {
-		log.Fatal(err)
-	}
-}
-
-// CORS middleware
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Allow requests from Heroku and localhost
-		w.Header().Set("Access-Control-Allow-Origin", "https://regatta-project.onrender.com")
-		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:8080") // Allow localhost for development
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
-
-// Handler functions
-func createRegatta(w http.ResponseWriter, r *http.Request) {
-	log.Printf("Received request to create a regatta")
-
-	var regatta Regatta
-	if err := json.NewDecoder(r.Body).Decode(&regatta); err != nil {
-		log.Printf("Error decoding request body: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	regatta.ID = uuid.New().String()
-	regatta.Status = "SCHEDULED"
-
-	stmt, err := db.DB.Prepare("INSERT INTO regattas(id, name, start_date, end_date, location, status) VALUES($1, $2, $3, $4, $5, $6)")
-	if err != nil {
-		log.Printf("Error preparing SQL statement: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer stmt.Close()
-
-	_, err = stmt.Exec(regatta.ID, regatta.Name, regatta.StartDate, regatta.EndDate, regatta.Location, regatta.Status)
-	if err != nil {
-		log.Printf("Error executing SQL statement: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	log.Printf("Successfully created regatta: %+v", regatta)
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(regatta)
-}
-
-func getRegattaStandings(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	regattaId := vars["regattaId"]
-
-	// Get all results for this regatta
-	rows, err := db.DB.Query(`
-		SELECT r.team_id, t.name, r.race_number, r.position, r.points 
-		FROM race_results r 
-		JOIN teams t ON r.team_id = t.id 
-		WHERE r.regatta_id = $1`, regattaId)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	standings := make(map[string]*TeamStanding)
-	for rows.Next() {
-		var result RaceResult
-		var teamName string
-		if err := rows.Scan(&result.TeamID, &teamName, &result.RaceNumber, &result.Position, &result.Points); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		if _, exists := standings[result.TeamID]; !exists {
-			standings[result.TeamID] = &TeamStanding{
-				TeamID:   result.TeamID,
-				TeamName: teamName,
-			}
-		}
-		standings[result.TeamID].Results = append(standings[result.TeamID].Results, result)
-		standings[result.TeamID].TotalPoints += result.Points
-	}
-
-	// Convert map to slice for output
-	standingsList := make([]TeamStanding, 0, len(standings))
-	for _, s := range standings {
-		standingsList = append(standingsList, *s)
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(standingsList)
-}
-
-func getAllRegattas(w http.ResponseWriter, r *http.Request) {
-	log.Printf("Received request to get all regattas")
-
-	rows, err := db.DB.Query("SELECT id, name, start_date, end_date, location FROM regattas")
-	if err != nil {
-		log.Printf("Error fetching regattas: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	var regattas []Regatta
-	for rows.Next() {
-		var regatta Regatta
-		if err := rows.Scan(&regatta.ID, &regatta.Name, &regatta.StartDate, &regatta.EndDate, &regatta.Location); err != nil {
-			log.Printf("Error scanning regatta: %v", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		regattas = append(regattas, regatta)
-	}
-
-	log.Printf("Successfully retrieved %d regattas", len(regattas))
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(regattas)
-}
-
-func getRegatta(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
-
-	log.Printf("Received request to get regatta with ID: %s", id)
-
-	var regatta Regatta
-	err := db.DB.QueryRow("SELECT id, name, start_date, end_date, location, status FROM regattas WHERE id = $1", id).
-		Scan(&regatta.ID, &regatta.Name, &regatta.StartDate, &regatta.EndDate, &regatta.Location, &regatta.Status)
-
-	if err != nil {
-		log.Printf("Error fetching regatta: %v", err)
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	}
-
-	log.Printf("Successfully retrieved regatta: %+v", regatta)
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(regatta)
-}
-
-func updateRegatta(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
-
-	log.Printf("Received request to update regatta with ID: %s", id)
-
-	var regatta Regatta
-	if err := json.NewDecoder(r.Body).Decode(&regatta); err != nil {
-		log.Printf("Error decoding request body: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	_, err := db.DB.Exec("UPDATE regattas SET name=$1, start_date=$2, end_date=$3, location=$4, status=$5 WHERE id=$6",
-		regatta.Name, regatta.StartDate, regatta.EndDate, regatta.Location, regatta.Status, id)
-	if err != nil {
-		log.Printf("Error updating regatta: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	log.Printf("Successfully updated regatta with ID: %s", id)
-
-	w.Header().Set("Content-Type", "application/json")
-	regatta.ID = id
-	json.NewEncoder(w).Encode(regatta)
-}
-
-func deleteRegatta(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
-
-	log.Printf("Received request to delete regatta with ID: %s", id)
-
-	_, err := db.DB.Exec("DELETE FROM regattas WHERE id = $1", id)
-	if err != nil {
-		log.Printf("Error deleting regatta: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	log.Printf("Successfully deleted regatta with ID: %s", id)
-
-	w.WriteHeader(http.StatusNoContent)
-}
-
-func getRegattaTeams(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	regattaId := vars["regattaId"]
-
-	rows, err := db.DB.Query("SELECT id, name, regatta_id FROM teams WHERE regatta_id = $1", regattaId)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	var teams []Team
-	for rows.Next() {
-		var team Team
-		if err := rows.Scan(&team.ID, &team.Name, &team.RegattaID); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		teams = append(teams, team)
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(teams)
-}
-
-func addTeam(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	regattaId := vars["regattaId"]
-
-	var team Team
-	if err := json.NewDecoder(r.Body).Decode(&team); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	team.ID = uuid.New().String()
-	team.RegattaID = regattaId
-
-	_, err := db.DB.Exec("INSERT INTO teams(id, name, regatta_id) VALUES($1, $2, $3)",
-		team.ID, team.Name, team.RegattaID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(team)
-}
-
-func addRaceResults(w http.ResponseWriter, r *http.Request) {
-	log.Printf("addRaceResults handler called - Method: %s, URL: %s", r.Method, r.URL.Path)
-
-	vars := mux.Vars(r)
-	regattaId := vars["regattaId"]
-
-	log.Printf("Path variable - RegattaID: %s", regattaId)
-
-	var requestData struct {
-		RaceNumber int          `json:"raceNumber"`
-		Results    []RaceResult `json:"results"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		log.Printf("Error decoding request body: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	log.Printf("Received race number: %d", requestData.RaceNumber)
-	log.Printf("Received results: %+v", requestData.Results)
-
-	for _, result := range requestData.Results {
-		result.ID = uuid.New().String()
-
-		// Insert the race result into the database
-		_, err := db.DB.Exec("INSERT INTO race_results (id, regatta_id, team_id, race_number, position, points) VALUES ($1, $2, $3, $4, $5, $6)",
-			result.ID, result.RegattaID, result.TeamID, result.RaceNumber, result.Position, result.Points)
-
-		if err != nil {
-			log.Printf("Error adding race result to database: %v", err)
-			// Handle unique constraint violation
-			if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.Code == sqlite3.ErrConstraint {
-				log.Printf("Unique constraint failed for ID: %s, updating existing record", result.ID)
-				// Update logic here if necessary
-			} else {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-		} else {
-			log.Printf("Race result added successfully - TeamID: %s, RaceNumber: %d", result.TeamID, result.RaceNumber)
-		}
-	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
-
-func clearRegattaResults(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	regattaId := vars["regattaId"]
-
-	_, err := db.DB.Exec("DELETE FROM race_results WHERE regatta_id = $1", regattaId)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
-
-func getDashboardStats(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	stats := struct {
-		ActiveRegattas int `json:"activeRegattas"`
-		TotalTeams     int `json:"totalTeams"`
-		RacesCompleted int `json:"racesCompleted"`
-		UpcomingRaces  int `json:"upcomingRaces"`
-	}{}
-
-	// Get active regattas count
-	err := db.DB.QueryRow("SELECT COUNT(*) FROM regattas WHERE status = 'active'").Scan(&stats.ActiveRegattas)
-	if err != nil {
-		log.Printf("Error getting active regattas: %v", err)
-	}
-
-	// Get total teams count
-	err = db.DB.QueryRow("SELECT COUNT(*) FROM teams").Scan(&stats.TotalTeams)
-	if err != nil {
-		log.Printf("Error getting total teams: %v", err)
-	}
-
-	// Get completed races count
-	err = db.DB.QueryRow("SELECT COUNT(*) FROM (SELECT DISTINCT regatta_id, race_number FROM race_results)").Scan(&stats.RacesCompleted)
-	if err != nil {
-		log.Printf("Error getting completed races: %v", err)
-	}
-
-	// Get upcoming races count
-	err = db.DB.QueryRow("SELECT COUNT(*) FROM regattas WHERE status = 'SCHEDULED'").Scan(&stats.UpcomingRaces)
-	if err != nil {
-		log.Printf("Error getting upcoming races: %v", err)
-	}
-
-	json.NewEncoder(w).Encode(stats)
-}
-
-func deleteTeam(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	regattaId := vars["regattaId"]
-	teamId := vars["teamId"]
-
-	if regattaId == "" || teamId == "" {
-		http.Error(w, "regattaId and teamId are required", http.StatusBadRequest)
-		return
-	}
-
-	// First verify the team belongs to the regatta
-	var count int
-	err := db.DB.QueryRow("SELECT COUNT(*) FROM teams WHERE id = $1 AND regatta_id = $2", teamId, regattaId).Scan(&count)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	if count == 0 {
-		http.Error(w, "Team not found or doesn't belong to this regatta", http.StatusNotFound)
-		return
-	}
-
-	// Delete the team
-	_, err = db.DB.Exec("DELETE FROM teams WHERE id = $1 AND regatta_id = $2", teamId, regattaId)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
-}
-
-func updateTeam(w http.ResponseWriter, r *http.Request) {
-	// Request logging
-	log.Printf("updateTeam handler called - Method: %s, URL: %s", r.Method, r.URL.Path)
-	log.Printf("Request Headers: %+v", r.Header)
-
-	vars := mux.Vars(r)
-	regattaId := vars["regattaId"]
-	teamId := vars["teamId"]
-
-	log.Printf("Path variables - RegattaID: %s, TeamID: %s", regattaId, teamId)
-
-	if regattaId == "" || teamId == "" {
-		log.Printf("Error: Missing required parameters - RegattaID: %s, TeamID: %s", regattaId, teamId)
-		http.Error(w, "regattaId and teamId are required", http.StatusBadRequest)
-		return
-	}
-
-	// Read and log request body
-	var team Team
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.Printf("Error reading request body: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-	log.Printf("Received request body: %s", string(body))
-
-	// Parse JSON body
-	if err := json.Unmarshal(body, &team); err != nil {
-		log.Printf("Error parsing JSON body: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-	log.Printf("Parsed team data: %+v", team)
-
-	// Verify team exists and belongs to regatta
-	var count int
-	err = db.DB.QueryRow("SELECT COUNT(*) FROM teams WHERE id = $1 AND regatta_id = $2", teamId, regattaId).Scan(&count)
-	if err != nil {
-		log.Printf("Error checking team existence: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	if count == 0 {
-		log.Printf("Team not found or doesn't belong to regatta - TeamID: %s, RegattaID: %s", teamId, regattaId)
-		http.Error(w, "Team not found or doesn't belong to this regatta", http.StatusNotFound)
-		return
-	}
-	log.Printf("Team verification successful - Found %d matching teams", count)
-
-	// Update the team
-	result, err := db.DB.Exec("UPDATE teams SET name = $1 WHERE id = $2 AND regatta_id = $3",
-		team.Name, teamId, regattaId)
-	if err != nil {
-		log.Printf("Error executing update query: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		log.Printf("Error getting rows affected: %v", err)
-	} else {
-		log.Printf("Update successful - Rows affected: %d", rowsAffected)
-	}
-
-	// Return updated team
-	team.ID = teamId
-	team.RegattaID = regattaId
-	w.Header().Set("Content-Type", "application/json")
-
-	responseJSON, err := json.Marshal(team)
-	if err != nil {
-		log.Printf("Error marshaling response: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	log.Printf("Sending response: %s", string(responseJSON))
-
-	json.NewEncoder(w).Encode(team)
-}
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"regatta-project/pkg/db"
+	"regatta-project/pkg/handicap"
+	"regatta-project/pkg/repository"
+	"regatta-project/pkg/scoring"
+
+	"github.com/gorilla/mux"
+)
+
+// Define the base URL for the API
+var baseURL = os.Getenv("BASE_URL")
+
+// repo reads and writes regattas and teams
+var repo *repository.Repository
+
+// Types
+// Regattas and teams are stored through the repository
+type Regatta = repository.Regatta
+type Team = repository.Team
+
+type RaceResult = repository.RaceResult
+
+type RaceScores struct {
+	RaceNumber int          `json:"raceNumber"`
+	Scores     []RaceResult `json:"scores"`
+}
+
+type TeamStanding struct {
+	Rank     int    `json:"rank"`
+	TeamID   string `json:"teamId"`
+	TeamName string `json:"name"`
+	EntryDetails
+	GrossPoints float64      `json:"grossPoints"`
+	NetPoints   float64      `json:"netPoints"`
+	Results     []RaceResult `json:"results"`
+}
+
+func main() {
+	// Initialize database
+	if err := db.InitDB(); err != nil {
+		log.Fatal(err)
+	}
+	defer db.DB.Close()
+	repo = repository.New(db.DB)
+
+	if err := bootstrapAdmin(); err != nil {
+		log.Fatal(err)
+	}
+
+	router := mux.NewRouter()
+
+	// Unknown routes get the same JSON error body as the handlers
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpError(w, "No route for "+r.URL.Path, http.StatusNotFound)
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpError(w, r.Method+" is not allowed on "+r.URL.Path, http.StatusMethodNotAllowed)
+	})
+
+	// Enable CORS
+	router.Use(corsMiddleware)
+
+	// Resolve bearer tokens; routes that change data require a role
+	router.Use(authenticate)
+
+	// Keep each club to its own regattas and series
+	router.Use(scopeToOrganisation)
+
+	// API routes
+	router.HandleFunc("/api/auth/login", login).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/auth/logout", logout).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/auth/me", requireRole(getCurrentUser, roles...)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/organisations", getOrganisations).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/organisations", requireRole(createOrganisation)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/organisations/{organisationId}", getOrganisation).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/organisations/{organisationId}", requireRole(updateOrganisation)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/users", requireRole(getUsers)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/users", requireRole(createUser)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/users/{userId}", requireRole(updateUser)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/users/{userId}", requireRole(deleteUser)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/regattas", requireRole(createRegatta, RoleRaceOfficer)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/regattas", getAllRegattas).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{id}", getRegatta).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{id}", requireRole(updateRegatta, RoleRaceOfficer)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/regattas/{id}", requireRole(deleteRegatta, RoleRaceOfficer)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/teams", getRegattaTeams).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/teams", requireRole(addTeam, RoleRaceOfficer)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/results", requireRole(addRaceResults, RoleScorer)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/results/handicap", requireRole(addHandicapResults, RoleScorer)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/standings", getRegattaStandings).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/standings/stream", streamStandings).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/results/sheet", getResultSheet).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/results", requireRole(clearRegattaResults, RoleScorer)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/teams/import", requireRole(importTeamsCSV, RoleRaceOfficer)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/results/import", requireRole(importResultsCSV, RoleScorer)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/regattas/import/sailwave", requireRole(importSailwave, RoleScorer)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/export/sailwave", requireRole(exportSailwave, staffRoles...)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/export/xrr", exportXRR).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/export/xrr/validation", validateXRR).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/dashboard/stats", getDashboardStats).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/series", requireRole(createSeries, RoleRaceOfficer)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/series", getAllSeries).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/series/{seriesId}", getSeries).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/series/{seriesId}", requireRole(updateSeries, RoleRaceOfficer)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/series/{seriesId}", requireRole(deleteSeries, RoleRaceOfficer)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/series/{seriesId}/regattas", requireRole(addSeriesRegatta, RoleRaceOfficer)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/series/{seriesId}/regattas/{regattaId}", requireRole(removeSeriesRegatta, RoleRaceOfficer)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/series/{seriesId}/standings", getSeriesStandings).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/teams/{teamId}", requireRole(deleteTeam, RoleRaceOfficer)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/teams/{teamId}", requireRole(updateTeam, RoleRaceOfficer)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/fleets", getRegattaFleets).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/fleets", requireRole(addFleet, RoleRaceOfficer)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/fleets/{fleetId}", requireRole(updateFleet, RoleRaceOfficer)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/fleets/{fleetId}", requireRole(deleteFleet, RoleRaceOfficer)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/results/history", requireRole(getResultHistory, staffRoles...)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/standings/versions", requireRole(getStandingsVersions, staffRoles...)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/standings/versions/{version}", requireRole(getStandingsVersion, staffRoles...)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/publish", requireRole(publishRegatta, RoleScorer)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/published", getPublishedResults).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/publications", getPublications).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/races/{raceId}/publish", requireRole(publishRace, RoleScorer)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/protests", getRegattaProtests).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/protests", requireRole(lodgeProtest, RoleRaceOfficer, RoleJury)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/protests/{protestId}", getProtest).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/protests/{protestId}", requireRole(updateProtest, RoleJury)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/protests/{protestId}/decision", requireRole(decideProtest, RoleJury)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/races", requireRole(createRace, RoleRaceOfficer)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/races", getRegattaRaces).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/races/{raceId}", getRace).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/races/{raceId}", requireRole(updateRace, RoleRaceOfficer)).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/races/{raceId}", requireRole(deleteRace, RoleRaceOfficer)).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/races/{raceId}/status", requireRole(setRaceStatus, RoleRaceOfficer)).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/races/{raceId}/finishes", requireRole(getRaceFinishes, staffRoles...)).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/regattas/{regattaId}/races/{raceId}/finishes", requireRole(recordFinishes, RoleScorer)).Methods("POST", "OPTIONS")
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8081"
+	}
+
+	// Log the server starting address
+	if port == "8081" { // Default port, log with baseURL and port
+		log.Printf("API Server starting on %s:%s", baseURL, port)
+	} else { // Custom port, log with baseURL only
+		log.Printf("API Server starting on %s", baseURL)
+	}
+
+	// Combine baseURL and port for ListenAndServe
+	address := baseURL
+	if port == "8081" {
+		address += ":" + port
+	}
+
+	if err := http.ListenAndServe(address, router); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// CORS middleware
+func corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Allow requests from Heroku and localhost
+		w.Header().Set("Access-Control-Allow-Origin", "https://regatta-project.onrender.com")
+		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:8080") // Allow localhost for development
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Change-Reason")
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Handler functions
+func createRegatta(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Received request to create a regatta")
+
+	var regatta Regatta
+	if err := json.NewDecoder(r.Body).Decode(&regatta); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	organisationId, err := organisationForCreate(r, regatta.OrganisationID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	regatta.OrganisationID = organisationId
+
+	if err := repo.CreateRegatta(&regatta); err != nil {
+		log.Printf("Error creating regatta: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	log.Printf("Successfully created regatta: %+v", regatta)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(regatta)
+}
+
+// getAllRegattas lists the regattas of the caller's organisation, or of
+// every organisation when the request is not scoped to one.
+func getAllRegattas(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Received request to get all regattas")
+
+	regattas, err := repo.ListRegattas(requestOrganisation(r))
+	if err != nil {
+		log.Printf("Error fetching regattas: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	log.Printf("Successfully retrieved %d regattas", len(regattas))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(regattas)
+}
+
+func getRegatta(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	log.Printf("Received request to get regatta with ID: %s", id)
+
+	regatta, err := repo.GetRegatta(id)
+	if err != nil {
+		log.Printf("Error fetching regatta: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	log.Printf("Successfully retrieved regatta: %+v", regatta)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(regatta)
+}
+
+func updateRegatta(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	log.Printf("Received request to update regatta with ID: %s", id)
+
+	// Load the regatta's current values so fields missing from the body are
+	// kept, such as the handicap system and protest time limit
+	regatta, err := repo.GetRegatta(id)
+	if err != nil {
+		log.Printf("Error loading regatta: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&regatta); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	regatta.ID = id
+	if err := repo.UpdateRegatta(&regatta); err != nil {
+		log.Printf("Error updating regatta: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	log.Printf("Successfully updated regatta with ID: %s", id)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(regatta)
+}
+
+func deleteRegatta(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	log.Printf("Received request to delete regatta with ID: %s", id)
+
+	if err := repo.DeleteRegatta(id); err != nil {
+		log.Printf("Error deleting regatta: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	log.Printf("Successfully deleted regatta with ID: %s", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func getRegattaTeams(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	teams, err := repo.ListTeams(regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(teams)
+}
+
+func addTeam(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	var team Team
+	if err := json.NewDecoder(r.Body).Decode(&team); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	team.RegattaID = regattaId
+	if err := repo.CreateTeam(&team); err != nil {
+		writeError(w, err)
+		return
+	}
+	notifyTeamsChanged(regattaId)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(team)
+}
+
+func addRaceResults(w http.ResponseWriter, r *http.Request) {
+	log.Printf("addRaceResults handler called - Method: %s, URL: %s", r.Method, r.URL.Path)
+
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	log.Printf("Path variable - RegattaID: %s", regattaId)
+
+	var requestData struct {
+		RaceNumber int          `json:"raceNumber"`
+		Results    []RaceResult `json:"results"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Received race number: %d", requestData.RaceNumber)
+	log.Printf("Received results: %+v", requestData.Results)
+
+	entries, err := repo.Entries(regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := checkDuplicateResults(requestData.Results, requestData.RaceNumber); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Validate every result before anything is written
+	for i := range requestData.Results {
+		result := &requestData.Results[i]
+		result.RegattaID = regattaId
+		if result.RaceNumber == 0 {
+			result.RaceNumber = requestData.RaceNumber
+		}
+
+		code, err := scoring.ParseCode(result.Code)
+		if err != nil {
+			log.Printf("Invalid code for TeamID %s: %v", result.TeamID, err)
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result.Code = string(code)
+
+		entry, exists := entries[result.TeamID]
+		if !exists {
+			log.Printf("Unknown TeamID %s for RegattaID: %s", result.TeamID, regattaId)
+			httpError(w, "Team not found or doesn't belong to this regatta: "+result.TeamID, http.StatusBadRequest)
+			return
+		}
+
+		if err := repo.CheckRaceFinished(regattaId, entry.FleetID, result.RaceNumber); err != nil {
+			log.Printf("Race %d rejected for results: %v", result.RaceNumber, err)
+			writeError(w, err)
+			return
+		}
+
+		if err := scoring.ValidatePenalty(code, result.Penalty); err != nil {
+			log.Printf("Invalid penalty for TeamID %s: %v", result.TeamID, err)
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		redress, err := scoring.ParseRedress(code, result.RedressMode, result.RedressPoints)
+		if err != nil {
+			log.Printf("Invalid redress for TeamID %s: %v", result.TeamID, err)
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result.RedressMode = string(redress)
+
+		result.Penalties, err = scoring.ParseScoringPenalties(code, result.Penalties)
+		if err != nil {
+			log.Printf("Invalid scoring penalties for TeamID %s: %v", result.TeamID, err)
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// A coded boat may have no finishing place, every other boat and a
+		// DPI boat needs one
+		if result.Position < 0 || (result.Position == 0 && (code == "" || code == scoring.DPI)) {
+			log.Printf("Invalid position %d for TeamID: %s", result.Position, result.TeamID)
+			httpError(w, "position must be 1 or greater unless a code is given", http.StatusBadRequest)
+			return
+		}
+
+		// Points are derived by the scoring engine below, never trusted from the client
+		result.Points = 0
+		result.CorrectedTime = 0
+	}
+
+	changes := newResultLog(r, regattaId)
+	err = changes.record(func(store *repository.Repository) error {
+		return saveRaceResults(store, changes, requestData.Results)
+	})
+	if err != nil {
+		log.Printf("Error storing race results: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func addHandicapResults(w http.ResponseWriter, r *http.Request) {
+	log.Printf("addHandicapResults handler called - Method: %s, URL: %s", r.Method, r.URL.Path)
+
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	// Elapsed times are in seconds, distance in nautical miles
+	var requestData struct {
+		RaceNumber int          `json:"raceNumber"`
+		Distance   float64      `json:"distance"`
+		Results    []RaceResult `json:"results"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	regatta, err := repo.GetRegatta(regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	system := regatta.HandicapSystem
+
+	entries, err := repo.Entries(regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	for i := range requestData.Results {
+		result := &requestData.Results[i]
+		result.RegattaID = regattaId
+		result.RaceNumber = requestData.RaceNumber
+
+		if entry, exists := entries[result.TeamID]; exists {
+			if err := repo.CheckRaceFinished(regattaId, entry.FleetID, result.RaceNumber); err != nil {
+				log.Printf("Race %d rejected for results: %v", result.RaceNumber, err)
+				writeError(w, err)
+				return
+			}
+		}
+	}
+
+	if err := checkDuplicateResults(requestData.Results, requestData.RaceNumber); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := derivePositions(system, entries, requestData.Distance, requestData.Results); err != nil {
+		log.Printf("Error computing corrected times: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	changes := newResultLog(r, regattaId)
+	err = changes.record(func(store *repository.Repository) error {
+		return saveRaceResults(store, changes, requestData.Results)
+	})
+	if err != nil {
+		log.Printf("Error storing race results: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	teams, err := regattaTeams(repo, regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	for i := range requestData.Results {
+		requestData.Results[i].EntryDetails = teams[requestData.Results[i].TeamID].EntryDetails
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(requestData.Results)
+}
+
+// derivePositions computes corrected times from the elapsed times of results
+// and assigns finishing positions in corrected-time order within each fleet.
+// Boats with a code take no part in the order and keep position zero.
+func derivePositions(system handicap.System, entries map[string]repository.Entry, distance float64, results []RaceResult) error {
+	finishes := make(map[string][]handicap.Finish)
+	for i := range results {
+		result := &results[i]
+		result.Position = 0
+		result.Points = 0
+		result.CorrectedTime = 0
+
+		entry, exists := entries[result.TeamID]
+		if !exists {
+			return fmt.Errorf("team %s doesn't belong to this regatta", result.TeamID)
+		}
+
+		code, err := scoring.ParseCode(result.Code)
+		if err != nil {
+			return err
+		}
+		if err := scoring.ValidatePenalty(code, result.Penalty); err != nil {
+			return err
+		}
+		redress, err := scoring.ParseRedress(code, result.RedressMode, result.RedressPoints)
+		if err != nil {
+			return err
+		}
+		result.RedressMode = string(redress)
+		result.Penalties, err = scoring.ParseScoringPenalties(code, result.Penalties)
+		if err != nil {
+			return err
+		}
+		result.Code = string(code)
+		if code != "" {
+			continue
+		}
+
+		if result.ElapsedTime <= 0 {
+			return fmt.Errorf("team %s: elapsed time must be greater than zero unless a code is given", result.TeamID)
+		}
+		finishes[entry.FleetID] = append(finishes[entry.FleetID], handicap.Finish{
+			TeamID:  result.TeamID,
+			Rating:  entry.Rating,
+			Elapsed: time.Duration(result.ElapsedTime * float64(time.Second)),
+		})
+	}
+
+	byTeam := make(map[string]handicap.Finish)
+	for _, fleetFinishes := range finishes {
+		ranked, err := system.Rank(fleetFinishes, distance)
+		if err != nil {
+			return err
+		}
+		for _, finish := range ranked {
+			byTeam[finish.TeamID] = finish
+		}
+	}
+	for i := range results {
+		if finish, ok := byTeam[results[i].TeamID]; ok && results[i].Code == "" {
+			results[i].Position = finish.Position
+			results[i].CorrectedTime = finish.Corrected.Seconds()
+		}
+	}
+
+	return nil
+}
+
+// checkDuplicateResults rejects results listing a team more than once in
+// a race. Results without a race number belong to raceNumber.
+func checkDuplicateResults(results []RaceResult, raceNumber int) error {
+	seen := make(map[string]bool)
+	for _, result := range results {
+		number := result.RaceNumber
+		if number == 0 {
+			number = raceNumber
+		}
+		key := fmt.Sprintf("%s/%d", result.TeamID, number)
+		if seen[key] {
+			return fmt.Errorf("team %s is listed more than once for race %d", result.TeamID, number)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// saveRaceResults stores validated results through store, replacing any
+// result a team already has for the race, records them in changes, and
+// rescores the races they belong to. A replaced result keeps the code,
+// penalty and redress of a protest decision in force.
+func saveRaceResults(store *repository.Repository, changes *resultLog, results []RaceResult) error {
+	regattaId := changes.regattaId
+	decisions := make(map[int]map[string]RaceResult)
+	races := make(map[int]bool)
+	for _, result := range results {
+		if _, loaded := decisions[result.RaceNumber]; !loaded {
+			decided, err := decidedResults(store, regattaId, result.RaceNumber)
+			if err != nil {
+				return err
+			}
+			decisions[result.RaceNumber] = decided
+		}
+		if decided, exists := decisions[result.RaceNumber][result.TeamID]; exists {
+			result.Code = decided.Code
+			result.Penalty = decided.Penalty
+			result.RedressMode = decided.RedressMode
+			result.RedressPoints = decided.RedressPoints
+		}
+
+		before, err := store.SaveResult(&result)
+		if err != nil {
+			log.Printf("Error saving race result: %v", err)
+			return err
+		}
+		if before != nil {
+			changes.amended(ResultAmended, *before)
+		} else {
+			changes.created(result)
+		}
+		races[result.RaceNumber] = true
+	}
+
+	// Rescore the affected races so stored points account for ties and codes
+	for raceNumber := range races {
+		if err := rescoreRace(store, regattaId, raceNumber); err != nil {
+			log.Printf("Error rescoring race %d: %v", raceNumber, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decidedResults returns the stored results of a race, by team, whose code,
+// penalty and redress were set by a protest decision still in force.
+func decidedResults(store *repository.Repository, regattaId string, raceNumber int) (map[string]RaceResult, error) {
+	decided, err := store.DecidedResults(regattaId, raceNumber)
+	if err != nil || len(decided) == 0 {
+		return nil, err
+	}
+	stored, err := store.RaceResults(regattaId, raceNumber, "")
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]RaceResult)
+	for _, result := range stored {
+		if decided[result.ID] {
+			results[result.TeamID] = result
+		}
+	}
+	return results, nil
+}
+
+// rescoreRace recomputes the points in store of every result in a race.
+// Each fleet is scored on its own.
+func rescoreRace(store *repository.Repository, regattaId string, raceNumber int) error {
+	entries, err := store.Entries(regattaId)
+	if err != nil {
+		return err
+	}
+	stored, err := store.RaceResults(regattaId, raceNumber, "")
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		counts[entry.FleetID]++
+	}
+
+	ids := make(map[string][]string)
+	results := make(map[string][]scoring.Result)
+	for _, result := range stored {
+		entry, exists := entries[result.TeamID]
+		if !exists {
+			continue
+		}
+		ids[entry.FleetID] = append(ids[entry.FleetID], result.ID)
+		results[entry.FleetID] = append(results[entry.FleetID], scoring.Result{
+			TeamID:     result.TeamID,
+			RaceNumber: raceNumber,
+			Position:   result.Position,
+			Code:       scoring.Code(result.Code),
+			Penalty:    result.Penalty,
+			Penalties:  result.Penalties,
+		})
+	}
+
+	for fleetId, fleetResults := range results {
+		for i, result := range scoring.ScoreRace(fleetResults, counts[fleetId]) {
+			if err := store.SetResultPoints(ids[fleetId][i], result.Points); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func clearRegattaResults(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	changes := newResultLog(r, regattaId)
+	err := changes.record(func(store *repository.Repository) error {
+		deleted, err := store.DeleteRegattaResults(regattaId)
+		if err != nil {
+			return err
+		}
+		changes.deleted(deleted)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error clearing race results: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getDashboardStats counts the regattas, teams and races of the caller's
+// organisation, or of every organisation when the request is not scoped.
+func getDashboardStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	organisationId := requestOrganisation(r)
+
+	stats, err := repo.DashboardStats(organisationId)
+	if err != nil {
+		log.Printf("Error getting dashboard stats: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(stats)
+}
+
+func deleteTeam(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+	teamId := vars["teamId"]
+
+	if regattaId == "" || teamId == "" {
+		httpError(w, "regattaId and teamId are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := repo.DeleteTeam(regattaId, teamId); err != nil {
+		writeError(w, err)
+		return
+	}
+	notifyTeamsChanged(regattaId)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func updateTeam(w http.ResponseWriter, r *http.Request) {
+	// Request logging
+	log.Printf("updateTeam handler called - Method: %s, URL: %s", r.Method, r.URL.Path)
+
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+	teamId := vars["teamId"]
+
+	log.Printf("Path variables - RegattaID: %s, TeamID: %s", regattaId, teamId)
+
+	if regattaId == "" || teamId == "" {
+		log.Printf("Error: Missing required parameters - RegattaID: %s, TeamID: %s", regattaId, teamId)
+		httpError(w, "regattaId and teamId are required", http.StatusBadRequest)
+		return
+	}
+
+	// Load the team's current values so fields missing from the body are kept
+	team, err := repo.GetTeam(regattaId, teamId)
+	if err != nil {
+		log.Printf("Error loading TeamID %s: %v", teamId, err)
+		writeError(w, err)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&team); err != nil {
+		log.Printf("Error parsing JSON body: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	team.ID = teamId
+	team.RegattaID = regattaId
+
+	if err := repo.UpdateTeam(&team); err != nil {
+		log.Printf("Error updating TeamID %s: %v", teamId, err)
+		writeError(w, err)
+		return
+	}
+	log.Printf("Updated TeamID: %s", teamId)
+	notifyTeamsChanged(regattaId)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(team)
+}
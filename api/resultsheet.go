@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"regatta-project/pkg/pdf"
+
+	"github.com/gorilla/mux"
+)
+
+// Types
+
+// resultSheet is the official results of a regatta laid out for printing:
+// one table per fleet with a column per race.
+type resultSheet struct {
+	Regatta   Regatta
+	Status    string
+	Generated time.Time
+	Fleets    []sheetFleet
+}
+
+type sheetFleet struct {
+	Name        string
+	RaceNumbers []int
+	Rows        []sheetRow
+}
+
+// sheetRow is a boat's line on the sheet. Race cells show the points scored
+// followed by any scoring code, discarded scores in brackets.
+type sheetRow struct {
+	Rank       int
+	SailNumber string
+	Name       string
+	Club       string
+	Races      []string
+	Total      string
+	Net        string
+}
+
+// getResultSheet serves the official results sheet of a regatta as a
+// standalone HTML document, or as PDF with ?format=pdf. The public get the
+// latest published standings.
+func getResultSheet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	sheet, err := buildResultSheet(r, regattaId)
+	if err != nil {
+		log.Printf("Error building results sheet: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "", "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := resultSheetTemplate.Execute(w, sheet); err != nil {
+			log.Printf("Error rendering results sheet: %v", err)
+		}
+	case "pdf":
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", sheet.Regatta.Name+" results.pdf"))
+		if err := renderResultSheetPDF(sheet).Write(w); err != nil {
+			log.Printf("Error writing results sheet PDF: %v", err)
+		}
+	default:
+		httpError(w, "format must be html or pdf", http.StatusBadRequest)
+	}
+}
+
+// buildResultSheet lays out the standings of a regatta the user of the
+// request may see, headed by their publication status. It returns a
+// repository.ErrNotFound error when the regatta does not exist.
+func buildResultSheet(r *http.Request, regattaId string) (*resultSheet, error) {
+	sheet := &resultSheet{Generated: time.Now().UTC()}
+
+	standings, publication, err := viewableStandings(r, regattaId)
+	if err != nil {
+		return nil, err
+	}
+
+	regatta, err := repo.GetRegatta(regattaId)
+	if err != nil {
+		return nil, err
+	}
+	sheet.Regatta = regatta
+
+	switch {
+	case publication != nil:
+		sheet.Status = publicationLabels[publication.Status] + ", published " + publication.PublishedAt.Format("2006-01-02 15:04 MST")
+	case seesWorkingResults(r):
+		sheet.Status = "Working results, not published"
+	}
+
+	for _, group := range standings {
+		fleet := sheetFleet{Name: group.FleetName}
+
+		raced := make(map[int]bool)
+		for _, standing := range group.Standings {
+			for _, result := range standing.Results {
+				raced[result.RaceNumber] = true
+			}
+		}
+		for number := range raced {
+			fleet.RaceNumbers = append(fleet.RaceNumbers, number)
+		}
+		sort.Ints(fleet.RaceNumbers)
+
+		for _, standing := range group.Standings {
+			row := sheetRow{
+				Rank:       standing.Rank,
+				SailNumber: standing.SailNumber,
+				Name:       standing.TeamName,
+				Club:       strings.TrimSpace(standing.Club + " " + standing.Country),
+				Total:      formatPoints(standing.GrossPoints),
+				Net:        formatPoints(standing.NetPoints),
+			}
+			if standing.BoatName != "" && standing.BoatName != standing.TeamName {
+				row.Name += " (" + standing.BoatName + ")"
+			}
+
+			byRace := make(map[int]RaceResult)
+			for _, result := range standing.Results {
+				byRace[result.RaceNumber] = result
+			}
+			for _, number := range fleet.RaceNumbers {
+				result, exists := byRace[number]
+				if !exists {
+					row.Races = append(row.Races, "-")
+					continue
+				}
+				cell := formatPoints(result.Points)
+				if result.Code != "" {
+					cell += " " + result.Code
+				}
+				for _, penalty := range result.Penalties {
+					cell += " " + string(penalty.Code)
+				}
+				if result.Discarded {
+					cell = "(" + cell + ")"
+				}
+				row.Races = append(row.Races, cell)
+			}
+			fleet.Rows = append(fleet.Rows, row)
+		}
+		sheet.Fleets = append(sheet.Fleets, fleet)
+	}
+
+	return sheet, nil
+}
+
+// formatPoints writes points without trailing zeros, e.g. 3 or 2.5.
+func formatPoints(points float64) string {
+	return strconv.FormatFloat(points, 'f', -1, 64)
+}
+
+// renderResultSheetPDF draws the sheet on landscape A4 pages, repeating the
+// table header on every page.
+func renderResultSheetPDF(sheet *resultSheet) *pdf.Document {
+	doc := pdf.New(pdf.A4Height, pdf.A4Width)
+	width, height := doc.Size()
+	const margin = 36.0
+	const lineHeight = 14.0
+
+	y := 0.0
+	newPage := func() {
+		doc.AddPage()
+		doc.Text(margin, margin+14, 16, true, sheet.Regatta.Name)
+		doc.Text(margin, margin+30, 10, false, regattaSubtitle(sheet.Regatta))
+		if sheet.Status != "" {
+			doc.Text(margin, margin+44, 10, true, sheet.Status)
+		}
+		footer := "Generated " + sheet.Generated.Format("2006-01-02 15:04 MST")
+		doc.Text(margin, height-margin/2, 8, false, footer)
+		y = margin + 62
+	}
+	newPage()
+
+	for _, fleet := range sheet.Fleets {
+		// Fixed columns first, the remaining width shared by the races
+		columns := []float64{30, 60, 170, 80}
+		raceWidth := 40.0
+		if n := len(fleet.RaceNumbers); n > 0 {
+			available := width - 2*margin - 30 - 60 - 170 - 80 - 2*45
+			if w := available / float64(n); w < raceWidth {
+				raceWidth = w
+			}
+		}
+		fontSize := 9.0
+		if raceWidth < 32 {
+			fontSize = 7
+		}
+		for range fleet.RaceNumbers {
+			columns = append(columns, raceWidth)
+		}
+		columns = append(columns, 45, 45)
+
+		header := []string{"Rank", "Sail No.", "Name", "Club"}
+		for _, number := range fleet.RaceNumbers {
+			header = append(header, fmt.Sprintf("R%d", number))
+		}
+		header = append(header, "Total", "Net")
+
+		drawRow := func(cells []string, bold bool) {
+			x := margin
+			for i, cell := range cells {
+				text := []rune(cell)
+				for pdf.TextWidth(string(text), fontSize, bold) > columns[i]-4 && len(text) > 1 {
+					text = text[:len(text)-1]
+				}
+				doc.Text(x, y, fontSize, bold, string(text))
+				x += columns[i]
+			}
+			y += lineHeight
+		}
+		drawHeader := func() {
+			if fleet.Name != "" {
+				doc.Text(margin, y, 12, true, fleet.Name)
+				y += lineHeight + 4
+			}
+			drawRow(header, true)
+			doc.Line(margin, y-lineHeight+4, width-margin, y-lineHeight+4)
+		}
+
+		if y+3*lineHeight > height-margin {
+			newPage()
+		}
+		drawHeader()
+		for _, row := range fleet.Rows {
+			if y > height-margin {
+				newPage()
+				drawHeader()
+			}
+			cells := []string{strconv.Itoa(row.Rank), row.SailNumber, row.Name, row.Club}
+			cells = append(cells, row.Races...)
+			cells = append(cells, row.Total, row.Net)
+			drawRow(cells, false)
+		}
+		y += lineHeight
+	}
+
+	return doc
+}
+
+// regattaSubtitle describes where and when a regatta was sailed.
+func regattaSubtitle(regatta Regatta) string {
+	parts := []string{}
+	if regatta.Location != "" {
+		parts = append(parts, regatta.Location)
+	}
+	if regatta.StartDate != "" {
+		dates := regatta.StartDate
+		if regatta.EndDate != "" && regatta.EndDate != regatta.StartDate {
+			dates += " to " + regatta.EndDate
+		}
+		parts = append(parts, dates)
+	}
+	if len(regatta.Discards) > 0 {
+		parts = append(parts, "Discards after races "+regatta.Discards.String())
+	}
+	return strings.Join(parts, " | ")
+}
+
+var resultSheetTemplate = template.Must(template.New("sheet").Funcs(template.FuncMap{
+	"subtitle": regattaSubtitle,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Regatta.Name}} - Results</title>
+<style>
+    body { font-family: Helvetica, Arial, sans-serif; margin: 2em; color: #000; }
+    h1 { margin-bottom: 0.2em; }
+    .subtitle { margin-top: 0; color: #333; }
+    .status { font-weight: bold; }
+    table { border-collapse: collapse; width: 100%; margin-bottom: 2em; font-size: 0.9em; }
+    th, td { border: 1px solid #999; padding: 0.3em 0.5em; text-align: left; }
+    th { background: #eee; }
+    td.points { text-align: right; white-space: nowrap; }
+    footer { font-size: 0.8em; color: #555; }
+    @media print { body { margin: 0; } th { background: none; } }
+</style>
+</head>
+<body>
+<h1>{{.Regatta.Name}}</h1>
+<p class="subtitle">{{subtitle .Regatta}}</p>
+{{if .Status}}<p class="status">{{.Status}}</p>{{end}}
+{{range .Fleets}}
+{{if .Name}}<h2>{{.Name}}</h2>{{end}}
+<table>
+    <thead>
+        <tr>
+            <th>Rank</th>
+            <th>Sail No.</th>
+            <th>Name</th>
+            <th>Club</th>
+            {{range .RaceNumbers}}<th>R{{.}}</th>{{end}}
+            <th>Total</th>
+            <th>Net</th>
+        </tr>
+    </thead>
+    <tbody>
+        {{range .Rows}}
+        <tr>
+            <td>{{.Rank}}</td>
+            <td>{{.SailNumber}}</td>
+            <td>{{.Name}}</td>
+            <td>{{.Club}}</td>
+            {{range .Races}}<td class="points">{{.}}</td>{{end}}
+            <td class="points">{{.Total}}</td>
+            <td class="points"><strong>{{.Net}}</strong></td>
+        </tr>
+        {{end}}
+    </tbody>
+</table>
+{{else}}
+<p>No results are available for this regatta.</p>
+{{end}}
+<footer>Generated {{.Generated.Format "2006-01-02 15:04 MST"}}</footer>
+</body>
+</html>
+`))
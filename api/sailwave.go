@@ -0,0 +1,370 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"regatta-project/pkg/racestatus"
+	"regatta-project/pkg/repository"
+	"regatta-project/pkg/sailwave"
+	"regatta-project/pkg/scoring"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// importSailwave creates a regatta with its fleets, teams, races and results
+// from a Sailwave .blw file, sent either as the request body or as the
+// "file" field of a multipart form. Nothing is written unless the whole file
+// is valid and stored.
+func importSailwave(w http.ResponseWriter, r *http.Request) {
+	log.Printf("importSailwave handler called - Method: %s, URL: %s", r.Method, r.URL.Path)
+
+	file, err := uploadReader(r)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	series, err := sailwave.Read(file)
+	if err != nil {
+		log.Printf("Error reading Sailwave file: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	regatta := Regatta{
+		ID:       uuid.New().String(),
+		Name:     series.Event,
+		Location: series.Venue,
+		Status:   "SCHEDULED",
+	}
+	if regatta.Name == "" {
+		regatta.Name = "Imported regatta"
+	}
+	for _, race := range series.Races {
+		if race.Date == "" {
+			continue
+		}
+		if regatta.StartDate == "" {
+			regatta.StartDate = race.Date
+		}
+		regatta.EndDate = race.Date
+	}
+
+	// Fleets are created from the distinct fleet names of the competitors
+	var fleets []Fleet
+	fleetIds := make(map[string]string)
+	for _, competitor := range series.Competitors {
+		if _, exists := fleetIds[competitor.Fleet]; exists || competitor.Fleet == "" {
+			continue
+		}
+		fleet := Fleet{ID: uuid.New().String(), RegattaID: regatta.ID, Name: competitor.Fleet}
+		fleetIds[fleet.Name] = fleet.ID
+		fleets = append(fleets, fleet)
+	}
+
+	teams := make([]Team, 0, len(series.Competitors))
+	teamIds := make(map[string]string)
+	teamFleets := make(map[string]string)
+	entries := make(map[string]int)
+	sailNumbers := make(map[string]bool)
+	for _, competitor := range series.Competitors {
+		team := sailwaveTeam(competitor)
+		team.ID = uuid.New().String()
+		team.RegattaID = regatta.ID
+		team.FleetID = fleetIds[competitor.Fleet]
+
+		team.Normalize()
+		if err := team.Validate(); err != nil {
+			httpError(w, fmt.Sprintf("competitor %s: %v", competitor.ID, err), http.StatusBadRequest)
+			return
+		}
+		if team.Rating < 0 {
+			httpError(w, fmt.Sprintf("competitor %s: rating must not be negative", competitor.ID), http.StatusBadRequest)
+			return
+		}
+		if team.SailNumber != "" {
+			key := team.FleetID + "\x00" + strings.ToUpper(team.SailNumber)
+			if sailNumbers[key] {
+				httpError(w, fmt.Sprintf("competitor %s: sail number %s is used twice in its fleet", competitor.ID, team.SailNumber), http.StatusBadRequest)
+				return
+			}
+			sailNumbers[key] = true
+		}
+
+		teamIds[competitor.ID] = team.ID
+		teamFleets[team.ID] = team.FleetID
+		entries[team.FleetID]++
+		teams = append(teams, team)
+	}
+
+	// Races are numbered in the order of their Sailwave IDs
+	raceNumbers := make(map[string]int)
+	for i, race := range series.Races {
+		raceNumbers[race.ID] = i + 1
+	}
+
+	raced := make(map[int]bool)
+	results := make([]RaceResult, 0, len(series.Results))
+	for _, result := range series.Results {
+		teamId, exists := teamIds[result.CompetitorID]
+		if !exists {
+			httpError(w, fmt.Sprintf("result for unknown competitor %s", result.CompetitorID), http.StatusBadRequest)
+			return
+		}
+		raceNumber, exists := raceNumbers[result.RaceID]
+		if !exists {
+			httpError(w, fmt.Sprintf("result for unknown race %s", result.RaceID), http.StatusBadRequest)
+			return
+		}
+
+		// Sailwave gives a DPI in points, which the fleet's DNF score turns
+		// into the percentage stored
+		code, err := scoring.ParseCode(result.Code)
+		penalty := 0.0
+		if result.Penalty > 0 {
+			penalty = result.Penalty / scoring.DNF.Points(entries[teamFleets[teamId]]) * 100
+		}
+		if err == nil {
+			err = scoring.ValidatePenalty(code, penalty)
+		}
+		var redress scoring.RedressMode
+		if err == nil {
+			redress, err = scoring.ParseRedress(code, result.RedressMode, result.RedressPoints)
+		}
+		var penalties []scoring.ScoringPenalty
+		if err == nil {
+			penalties = make([]scoring.ScoringPenalty, len(result.Penalties))
+			for i, p := range result.Penalties {
+				penalties[i] = scoring.ScoringPenalty{Code: scoring.Code(p.Code), Percent: p.Percent}
+			}
+			penalties, err = scoring.ParseScoringPenalties(code, penalties)
+		}
+		if err != nil {
+			httpError(w, fmt.Sprintf("competitor %s in race %s: %v", result.CompetitorID, result.RaceID, err), http.StatusBadRequest)
+			return
+		}
+
+		raced[raceNumber] = true
+		results = append(results, RaceResult{
+			RegattaID:     regatta.ID,
+			TeamID:        teamId,
+			RaceNumber:    raceNumber,
+			Position:      result.Position,
+			Code:          string(code),
+			Penalty:       penalty,
+			RedressMode:   string(redress),
+			RedressPoints: result.RedressPoints,
+			Penalties:     penalties,
+			ElapsedTime:   result.Elapsed.Seconds(),
+		})
+	}
+
+	// The regatta is new, so its results need no lock and are committed
+	// with everything else in one transaction
+	regatta.OrganisationID = requestOrganisation(r)
+	changes := newResultLog(r, regatta.ID)
+	var event *StandingsEvent
+	err = repo.Transaction(func(store *repository.Repository) error {
+		if err := store.InsertRegatta(regatta); err != nil {
+			return err
+		}
+		for _, fleet := range fleets {
+			if err := store.InsertFleet(fleet); err != nil {
+				return err
+			}
+		}
+		for _, team := range teams {
+			if err := store.InsertTeam(team); err != nil {
+				return err
+			}
+		}
+		for _, race := range series.Races {
+			number := raceNumbers[race.ID]
+			status := racestatus.Scheduled
+			if raced[number] {
+				status = racestatus.Finished
+			}
+			err := store.InsertRace(Race{
+				ID:         uuid.New().String(),
+				RegattaID:  regatta.ID,
+				RaceNumber: number,
+				Status:     status,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if err := saveRaceResults(store, changes, results); err != nil {
+			return err
+		}
+
+		var err error
+		event, err = changes.commit(store)
+		return err
+	})
+	if err != nil {
+		log.Printf("Error importing regatta: %v", err)
+		writeError(w, err)
+		return
+	}
+	if event != nil {
+		standingsStream.broadcast(*event)
+	}
+
+	log.Printf("Imported regatta %s with %d teams, %d races and %d results", regatta.ID, len(teams), len(series.Races), len(results))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(regatta)
+}
+
+// exportSailwave writes a regatta with its teams, races and results as a
+// Sailwave .blw file.
+func exportSailwave(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	regatta, err := repo.GetRegatta(regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	series, err := sailwaveSeries(regatta)
+	if err != nil {
+		log.Printf("Error exporting regatta %s: %v", regattaId, err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", regatta.Name+".blw"))
+	if err := sailwave.Write(w, series); err != nil {
+		log.Printf("Error writing Sailwave file: %v", err)
+	}
+}
+
+// sailwaveTeam converts a Sailwave competitor into a team. The team is named
+// after the boat, or the helm or sail number when the boat has no name.
+func sailwaveTeam(competitor sailwave.Competitor) Team {
+	team := Team{
+		Name:   competitor.Boat,
+		Rating: competitor.Rating,
+		EntryDetails: EntryDetails{
+			SailNumber: competitor.SailNumber,
+			BoatName:   competitor.Boat,
+			Class:      competitor.Class,
+			Helm:       competitor.Helm,
+			Crew:       strings.Split(competitor.Crew, ","),
+			Club:       competitor.Club,
+			Country:    competitor.Nation,
+		},
+	}
+	if team.Name == "" {
+		team.Name = competitor.Helm
+	}
+	if team.Name == "" {
+		team.Name = competitor.SailNumber
+	}
+	return team
+}
+
+// sailwaveSeries collects a regatta for export. Competitors are numbered in
+// team order and races keep their race numbers as Sailwave IDs.
+func sailwaveSeries(regatta Regatta) (*sailwave.Series, error) {
+	series := &sailwave.Series{Event: regatta.Name, Venue: regatta.Location}
+
+	fleets, err := repo.ListFleets(regatta.ID)
+	if err != nil {
+		return nil, err
+	}
+	fleetNames := make(map[string]string)
+	for _, fleet := range fleets {
+		fleetNames[fleet.ID] = fleet.Name
+	}
+
+	teams, err := repo.ListTeams(regatta.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	competitorIds := make(map[string]string)
+	teamFleets := make(map[string]string)
+	entries := make(map[string]int)
+	for _, team := range teams {
+		id := strconv.Itoa(len(series.Competitors) + 1)
+		competitorIds[team.ID] = id
+		teamFleets[team.ID] = team.FleetID
+		entries[team.FleetID]++
+		series.Competitors = append(series.Competitors, sailwave.Competitor{
+			ID:         id,
+			SailNumber: team.SailNumber,
+			Boat:       team.BoatName,
+			Class:      team.Class,
+			Helm:       team.Helm,
+			Crew:       strings.Join(team.Crew, ", "),
+			Club:       team.Club,
+			Nation:     team.Country,
+			Fleet:      fleetNames[team.FleetID],
+			Rating:     team.Rating,
+		})
+		if team.BoatName == "" && team.Helm == "" {
+			series.Competitors[len(series.Competitors)-1].Boat = team.Name
+		}
+	}
+
+	// Races of several fleets sharing a number are one Sailwave race, dated
+	// by the earliest start
+	races, err := repo.ListRaces(regatta.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, race := range races {
+		last := len(series.Races) - 1
+		if last < 0 || series.Races[last].ID != strconv.Itoa(race.RaceNumber) {
+			series.Races = append(series.Races, sailwave.Race{
+				ID:   strconv.Itoa(race.RaceNumber),
+				Name: fmt.Sprintf("R%d", race.RaceNumber),
+			})
+			last++
+		}
+		if race.StartTime == nil {
+			continue
+		}
+		date := race.StartTime.Format("2006-01-02")
+		if series.Races[last].Date == "" || date < series.Races[last].Date {
+			series.Races[last].Date = date
+		}
+	}
+
+	results, err := repo.ListResults(regatta.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		exported := sailwave.Result{
+			CompetitorID:  competitorIds[result.TeamID],
+			RaceID:        strconv.Itoa(result.RaceNumber),
+			Position:      result.Position,
+			Code:          result.Code,
+			RedressMode:   result.RedressMode,
+			RedressPoints: result.RedressPoints,
+			Elapsed:       time.Duration(result.ElapsedTime * float64(time.Second)),
+		}
+		if result.Penalty > 0 {
+			exported.Penalty = scoring.PenaltyPoints(result.Penalty, entries[teamFleets[result.TeamID]])
+		}
+		for _, penalty := range result.Penalties {
+			exported.Penalties = append(exported.Penalties, sailwave.ScoringPenalty{Code: string(penalty.Code), Percent: penalty.Percent})
+		}
+		series.Results = append(series.Results, exported)
+	}
+	return series, nil
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"regatta-project/pkg/repository"
+	"regatta-project/pkg/scoring"
+
+	"github.com/gorilla/mux"
+)
+
+// How teams of different regattas are recognised as the same boat
+const (
+	matchBySailNumber = repository.MatchBySailNumber
+	matchByHelm       = repository.MatchByHelm
+)
+
+// Types
+
+type Series = repository.Series
+
+// SeriesResult is a boat's place in one regatta of a series and the points
+// it scores. A boat missing from a regatta is scored DNC.
+type SeriesResult struct {
+	RegattaID   string  `json:"regattaId"`
+	RegattaRank int     `json:"regattaRank,omitempty"`
+	Code        string  `json:"code,omitempty"`
+	Points      float64 `json:"points"`
+	Discarded   bool    `json:"discarded"`
+}
+
+type SeriesStanding struct {
+	Rank     int    `json:"rank"`
+	TeamName string `json:"name"`
+	EntryDetails
+	GrossPoints float64        `json:"grossPoints"`
+	NetPoints   float64        `json:"netPoints"`
+	Results     []SeriesResult `json:"results"`
+}
+
+// SeriesFleetStandings are the series standings of one fleet. Fleets are
+// matched across regattas by name.
+type SeriesFleetStandings struct {
+	FleetName string           `json:"fleetName"`
+	Standings []SeriesStanding `json:"standings"`
+}
+
+func createSeries(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Received request to create a series")
+
+	var series Series
+	if err := json.NewDecoder(r.Body).Decode(&series); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	organisationId, err := organisationForCreate(r, series.OrganisationID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	series.OrganisationID = organisationId
+
+	if err := repo.CreateSeries(&series); err != nil {
+		log.Printf("Error creating series: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	log.Printf("Successfully created series: %+v", series)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
+
+func getAllSeries(w http.ResponseWriter, r *http.Request) {
+	list, err := repo.ListSeries(requestOrganisation(r))
+	if err != nil {
+		log.Printf("Error fetching series: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func getSeries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	seriesId := vars["seriesId"]
+
+	series, err := repo.GetSeries(seriesId)
+	if err != nil {
+		log.Printf("Error fetching series: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
+
+// updateSeries changes the name, discards or matching rule of a series.
+// Fields missing from the body keep their values; regattas are added and
+// removed through their own routes.
+func updateSeries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	seriesId := vars["seriesId"]
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	series, err := repo.GetSeries(seriesId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	regattaIds := series.RegattaIDs
+
+	if err := json.Unmarshal(body, &series); err != nil {
+		log.Printf("Error parsing JSON body: %v", err)
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	series.ID = seriesId
+	series.RegattaIDs = regattaIds
+
+	if err := repo.UpdateSeries(&series); err != nil {
+		log.Printf("Error updating series: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
+
+// deleteSeries removes a series. Its regattas are left untouched.
+func deleteSeries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	seriesId := vars["seriesId"]
+
+	log.Printf("Received request to delete series with ID: %s", seriesId)
+
+	if err := repo.DeleteSeries(seriesId); err != nil {
+		log.Printf("Error deleting series: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func addSeriesRegatta(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	seriesId := vars["seriesId"]
+
+	var requestData struct {
+		RegattaID string `json:"regattaId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := repo.AddSeriesRegatta(seriesId, requestData.RegattaID); err != nil {
+		log.Printf("Error adding regatta to series: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	series, err := repo.GetSeries(seriesId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
+
+func removeSeriesRegatta(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	seriesId := vars["seriesId"]
+	regattaId := vars["regattaId"]
+
+	if err := repo.RemoveSeriesRegatta(seriesId, regattaId); err != nil {
+		log.Printf("Error removing regatta from series: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func getSeriesStandings(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	seriesId := vars["seriesId"]
+
+	series, err := repo.GetSeries(seriesId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	standings, err := computeSeriesStandings(r, series)
+	if err != nil {
+		log.Printf("Error computing series standings: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(standings)
+}
+
+// computeSeriesStandings ranks the boats of a series from their standings in
+// each regatta, as the user of the request may see them. Each regatta counts
+// as one race of the series, scored by the boat's rank in its fleet; boats
+// missing from a regatta their fleet sailed score DNC. The series discard
+// schedule then applies across regattas.
+func computeSeriesStandings(r *http.Request, series Series) ([]SeriesFleetStandings, error) {
+	var fleetNames []string
+	results := make(map[string][]scoring.Result)
+	seen := make(map[string]bool)
+	boats := make(map[string]Team)
+
+	for i, regattaId := range series.RegattaIDs {
+		raceNumber := i + 1
+
+		teams, err := regattaTeams(repo, regattaId)
+		if err != nil {
+			return nil, err
+		}
+
+		standings, _, err := viewableStandings(r, regattaId)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, fleet := range standings {
+			if !seen[fleet.FleetName] {
+				fleetNames = append(fleetNames, fleet.FleetName)
+				seen[fleet.FleetName] = true
+			}
+
+			for _, standing := range fleet.Standings {
+				team := teams[standing.TeamID]
+				key := boatKey(series.MatchBy, team)
+				boats[key] = team
+				results[fleet.FleetName] = append(results[fleet.FleetName], scoring.Result{
+					TeamID:     key,
+					RaceNumber: raceNumber,
+					Position:   standing.Rank,
+				})
+			}
+		}
+	}
+
+	list := make([]SeriesFleetStandings, 0, len(fleetNames))
+	for _, fleetName := range fleetNames {
+		keys := make(map[string]bool)
+		for _, result := range results[fleetName] {
+			keys[result.TeamID] = true
+		}
+
+		// Boats missing from a regatta their fleet sailed score DNC there
+		scored := scoring.Score(results[fleetName], scoring.Config{
+			Entries:  len(keys),
+			Discards: series.Discards,
+		})
+
+		group := SeriesFleetStandings{
+			FleetName: fleetName,
+			Standings: make([]SeriesStanding, 0, len(scored)),
+		}
+		for _, s := range scored {
+			boat := boats[s.TeamID]
+			standing := SeriesStanding{
+				Rank:         s.Rank,
+				TeamName:     boat.Name,
+				EntryDetails: boat.EntryDetails,
+				GrossPoints:  s.GrossPoints,
+				NetPoints:    s.NetPoints,
+			}
+			for _, result := range s.Results {
+				standing.Results = append(standing.Results, SeriesResult{
+					RegattaID:   series.RegattaIDs[result.RaceNumber-1],
+					RegattaRank: result.Position,
+					Code:        string(result.Code),
+					Points:      result.Points,
+					Discarded:   result.Discarded,
+				})
+			}
+			group.Standings = append(group.Standings, standing)
+		}
+		list = append(list, group)
+	}
+
+	return list, nil
+}
+
+// boatKey identifies a team across the regattas of a series. Sail numbers
+// ignore case and spaces, helm names ignore case and extra spaces. A team
+// without the identifying field only matches itself.
+func boatKey(matchBy string, team Team) string {
+	var key string
+	switch matchBy {
+	case matchBySailNumber:
+		key = strings.ToUpper(strings.Join(strings.Fields(team.SailNumber), ""))
+	case matchByHelm:
+		key = strings.ToLower(strings.Join(strings.Fields(team.Helm), " "))
+	}
+	if key == "" {
+		return "team:" + team.ID
+	}
+	return matchBy + ":" + key
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"regatta-project/pkg/repository"
+	"regatta-project/pkg/scoring"
+
+	"github.com/gorilla/mux"
+)
+
+// FleetStandings are the standings of one fleet, scored independently of
+// the other fleets in the regatta. Teams without a fleet share the group
+// with an empty FleetID.
+type FleetStandings struct {
+	FleetID   string         `json:"fleetId"`
+	FleetName string         `json:"fleetName"`
+	Standings []TeamStanding `json:"standings"`
+}
+
+// getRegattaStandings serves the current standings of a regatta, or with
+// ?at= (RFC 3339) the standings version that was current at that time. The
+// public are served the latest published standings and may not ask for
+// earlier versions.
+func getRegattaStandings(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	if value := r.URL.Query().Get("at"); value != "" {
+		if !seesWorkingResults(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			httpError(w, "Sign in to see earlier standings", http.StatusUnauthorized)
+			return
+		}
+		at, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			httpError(w, "at must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		version, err := repo.StandingsVersionAt(regattaId, at.UTC())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(version.Standings)
+		return
+	}
+
+	standings, _, err := viewableStandings(r, regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(standings)
+}
+
+// computeStandings scores every fleet of a regatta from the race results
+// in store. It returns a repository.ErrNotFound error when the regatta does
+// not exist.
+func computeStandings(store *repository.Repository, regattaId string) ([]FleetStandings, error) {
+	// Get the discard schedule for this regatta
+	regatta, err := store.GetRegatta(regattaId)
+	if err != nil {
+		return nil, err
+	}
+
+	fleets, err := store.ListFleets(regattaId)
+	if err != nil {
+		return nil, err
+	}
+	// Teams without a fleet are scored together after the named fleets
+	fleets = append(fleets, Fleet{RegattaID: regattaId})
+
+	teams, err := regattaTeams(store, regattaId)
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]int)
+	for _, team := range teams {
+		entries[team.FleetID]++
+	}
+
+	// Get all results for this regatta
+	all, err := store.ListResults(regattaId)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string][]scoring.Result)
+	stored := make(map[string]map[int]RaceResult)
+	for _, result := range all {
+		team, ok := teams[result.TeamID]
+		if !ok {
+			continue
+		}
+
+		if _, exists := stored[result.TeamID]; !exists {
+			stored[result.TeamID] = make(map[int]RaceResult)
+		}
+		result.EntryDetails = team.EntryDetails
+		stored[result.TeamID][result.RaceNumber] = result
+		results[team.FleetID] = append(results[team.FleetID], scoring.Result{
+			TeamID:        result.TeamID,
+			RaceNumber:    result.RaceNumber,
+			Position:      result.Position,
+			Code:          scoring.Code(result.Code),
+			Penalty:       result.Penalty,
+			Redress:       scoring.RedressMode(result.RedressMode),
+			RedressPoints: result.RedressPoints,
+			Penalties:     result.Penalties,
+		})
+	}
+
+	// Entries without any result score DNC in every race their fleet sailed;
+	// the scoring engine does the same for races a boat missed
+	absent := make([]Team, 0)
+	for _, team := range teams {
+		if _, exists := stored[team.ID]; !exists && len(results[team.FleetID]) > 0 {
+			absent = append(absent, team)
+		}
+	}
+	sort.Slice(absent, func(i, j int) bool { return absent[i].Name < absent[j].Name })
+	for _, team := range absent {
+		raceNumbers := make(map[int]bool)
+		for _, result := range results[team.FleetID] {
+			if !raceNumbers[result.RaceNumber] {
+				raceNumbers[result.RaceNumber] = true
+				results[team.FleetID] = append(results[team.FleetID], scoring.Result{TeamID: team.ID, RaceNumber: result.RaceNumber, Code: scoring.DNC})
+			}
+		}
+	}
+
+	standings := make([]FleetStandings, 0, len(fleets))
+	for _, fleet := range fleets {
+		if len(results[fleet.ID]) == 0 {
+			continue
+		}
+
+		// Let the scoring engine compute points and totals
+		scored := scoring.Score(results[fleet.ID], scoring.Config{
+			Entries:  entries[fleet.ID],
+			Discards: regatta.Discards,
+		})
+
+		group := FleetStandings{
+			FleetID:   fleet.ID,
+			FleetName: fleet.Name,
+			Standings: make([]TeamStanding, 0, len(scored)),
+		}
+		for _, s := range scored {
+			standing := TeamStanding{
+				Rank:         s.Rank,
+				TeamID:       s.TeamID,
+				TeamName:     teams[s.TeamID].Name,
+				EntryDetails: teams[s.TeamID].EntryDetails,
+				GrossPoints:  s.GrossPoints,
+				NetPoints:    s.NetPoints,
+			}
+			for _, scoredResult := range s.Results {
+				result, exists := stored[s.TeamID][scoredResult.RaceNumber]
+				if !exists {
+					// A DNC the scoring engine gave for a race the boat missed
+					result = RaceResult{
+						RegattaID:    regattaId,
+						TeamID:       s.TeamID,
+						RaceNumber:   scoredResult.RaceNumber,
+						Code:         string(scoredResult.Code),
+						EntryDetails: teams[s.TeamID].EntryDetails,
+					}
+				}
+				result.Points = scoredResult.Points
+				result.Discarded = scoredResult.Discarded
+				standing.Results = append(standing.Results, result)
+			}
+			group.Standings = append(group.Standings, standing)
+		}
+		standings = append(standings, group)
+	}
+
+	return standings, nil
+}
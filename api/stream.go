@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Types of the events pushed on a regatta's standings stream
+const (
+	StreamSnapshot  = "snapshot"
+	StreamResults   = "results"
+	StreamTeams     = "teams"
+	StreamPublished = "published"
+	StreamUpdated   = "updated"
+)
+
+// streamKeepAlive is how often an idle stream is sent a comment so proxies
+// do not close it.
+const streamKeepAlive = 25 * time.Second
+
+// Types
+
+// StandingsEvent is pushed to the subscribers of a regatta's standings. A
+// snapshot is sent on connecting; results events carry the recorded result
+// changes and the standings version they produced, teams events follow entry
+// changes, and published events carry a new publication. The public are
+// sent updated events in place of results and teams events, saying only
+// that the working results changed.
+type StandingsEvent struct {
+	Type        string           `json:"type"`
+	RegattaID   string           `json:"regattaId"`
+	Version     int              `json:"version,omitempty"`
+	Changes     []ResultChange   `json:"changes,omitempty"`
+	Standings   []FleetStandings `json:"standings,omitempty"`
+	Publication *Publication     `json:"publication,omitempty"`
+}
+
+// standingsBroker fans standings events out to the streams subscribed to
+// each regatta.
+type standingsBroker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan StandingsEvent]struct{}
+}
+
+var standingsStream = &standingsBroker{subscribers: make(map[string]map[chan StandingsEvent]struct{})}
+
+func (b *standingsBroker) subscribe(regattaId string) chan StandingsEvent {
+	events := make(chan StandingsEvent, 16)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers[regattaId] == nil {
+		b.subscribers[regattaId] = make(map[chan StandingsEvent]struct{})
+	}
+	b.subscribers[regattaId][events] = struct{}{}
+	return events
+}
+
+func (b *standingsBroker) unsubscribe(regattaId string, events chan StandingsEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers[regattaId], events)
+	if len(b.subscribers[regattaId]) == 0 {
+		delete(b.subscribers, regattaId)
+	}
+}
+
+// watched reports whether any stream is subscribed to a regatta, so events
+// that are costly to build can be skipped.
+func (b *standingsBroker) watched(regattaId string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers[regattaId]) > 0
+}
+
+// broadcast sends an event to the subscribers of its regatta. A subscriber
+// too slow to keep up misses the event rather than holding up the request
+// that caused it; the next event carries the full standings again.
+func (b *standingsBroker) broadcast(event StandingsEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for events := range b.subscribers[event.RegattaID] {
+		select {
+		case events <- event:
+		default:
+			log.Printf("Dropped %s event for a slow subscriber of RegattaID: %s", event.Type, event.RegattaID)
+		}
+	}
+}
+
+// notifyTeamsChanged pushes the standings of a regatta after its entries
+// changed.
+func notifyTeamsChanged(regattaId string) {
+	if !standingsStream.watched(regattaId) {
+		return
+	}
+	standings, err := computeStandings(repo, regattaId)
+	if err != nil {
+		log.Printf("Error computing standings to stream: %v", err)
+		return
+	}
+	standingsStream.broadcast(StandingsEvent{Type: StreamTeams, RegattaID: regattaId, Standings: standings})
+}
+
+// streamStandings holds open a Server-Sent Events stream of a regatta's
+// standings: a snapshot of the current standings, then an event whenever
+// its results or entries change or results are published. The public get
+// the latest published standings and no unpublished results.
+func streamStandings(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+	working := seesWorkingResults(r)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httpError(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before reading the snapshot so no change falls in between
+	events := standingsStream.subscribe(regattaId)
+	defer standingsStream.unsubscribe(regattaId, events)
+
+	snapshot := StandingsEvent{Type: StreamSnapshot, RegattaID: regattaId}
+	standings, publication, err := viewableStandings(r, regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	snapshot.Standings = standings
+	snapshot.Publication = publication
+
+	if working {
+		snapshot.Version, err = repo.LatestStandingsVersion(regattaId)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	log.Printf("Streaming standings of RegattaID: %s", regattaId)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	if err := writeStandingsEvent(w, snapshot); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			log.Printf("Standings stream of RegattaID %s closed", regattaId)
+			return
+		case event := <-events:
+			if !working && event.Type != StreamPublished {
+				event = StandingsEvent{Type: StreamUpdated, RegattaID: regattaId}
+			}
+			if err := writeStandingsEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeStandingsEvent writes an event in the Server-Sent Events format,
+// named after its type.
+func writeStandingsEvent(w http.ResponseWriter, event StandingsEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"regatta-project/pkg/xrr"
+
+	"github.com/gorilla/mux"
+)
+
+// exportXRR serves a regatta's entries, races and scored results in the
+// World Sailing XML Results Reporting format. The public get the published
+// results.
+func exportXRR(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	standings, _, err := viewableStandings(r, regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	doc, err := buildXRR(regattaId, standings)
+	if err != nil {
+		log.Printf("Error building XRR export: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", doc.Event.Title+".xml"))
+	if err := doc.Write(w); err != nil {
+		log.Printf("Error writing XRR export: %v", err)
+	}
+}
+
+// validateXRR reports what a regatta is missing before its XRR export is
+// accepted by a federation, such as sail numbers or nationalities.
+func validateXRR(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	regattaId := vars["regattaId"]
+
+	standings, _, err := viewableStandings(r, regattaId)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	doc, err := buildXRR(regattaId, standings)
+	if err != nil {
+		log.Printf("Error building XRR export: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	problems := doc.Validate()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Valid    bool          `json:"valid"`
+		Problems []xrr.Problem `json:"problems"`
+	}{len(problems) == 0, problems})
+}
+
+// buildXRR collects a regatta for export with the given standings. It
+// returns a repository.ErrNotFound error when the regatta does not exist.
+func buildXRR(regattaId string, standings []FleetStandings) (*xrr.Document, error) {
+	now := time.Now().UTC()
+	doc := &xrr.Document{
+		Type:    "Results",
+		Version: "1.0",
+		Date:    now.Format("2006-01-02"),
+		Time:    now.Format("15:04:05"),
+	}
+
+	regatta, err := repo.GetRegatta(regattaId)
+	if err != nil {
+		return nil, err
+	}
+	doc.Event.EventID = regatta.ID
+	doc.Event.Title = regatta.Name
+	doc.Event.StartDate = regatta.StartDate
+	doc.Event.EndDate = regatta.EndDate
+	doc.Event.Venue = regatta.Location
+
+	teams, err := regattaTeams(repo, regattaId)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(teams))
+	for id := range teams {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return teams[ids[i]].Name < teams[ids[j]].Name })
+
+	for _, id := range ids {
+		team := teams[id]
+		boatId := "B" + team.ID
+		doc.Boats = append(doc.Boats, xrr.Boat{BoatID: boatId, SailNumber: team.SailNumber, BoatName: team.BoatName})
+
+		xrrTeam := xrr.Team{TeamID: team.ID, BoatID: boatId, NOC: team.Country, TeamName: team.Name, Club: team.Club}
+		addPerson := func(name, position string) {
+			if name == "" {
+				return
+			}
+			personId := fmt.Sprintf("P%s-%d", team.ID, len(xrrTeam.Crew)+1)
+			given, family := splitName(name)
+			doc.Persons = append(doc.Persons, xrr.Person{PersonID: personId, GivenName: given, FamilyName: family, NOC: team.Country})
+			xrrTeam.Crew = append(xrrTeam.Crew, xrr.Crew{PersonID: personId, Position: position})
+		}
+		addPerson(team.Helm, "S")
+		for _, name := range team.Crew {
+			addPerson(name, "C")
+		}
+		doc.Teams = append(doc.Teams, xrrTeam)
+	}
+
+	races, err := xrrRaces(regattaId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range standings {
+		division := xrr.Division{DivisionID: group.FleetID, Title: group.FleetName}
+		if division.DivisionID == "" {
+			division.DivisionID = regattaId
+			division.Title = doc.Event.Title
+		}
+
+		classes := make(map[string]bool)
+		for _, standing := range group.Standings {
+			if standing.Class != "" {
+				classes[standing.Class] = true
+			}
+			result := xrr.TRResult{
+				TeamID:      standing.TeamID,
+				Rank:        standing.Rank,
+				TotalPoints: standing.GrossPoints,
+				NetPoints:   standing.NetPoints,
+			}
+			for _, raceResult := range standing.Results {
+				result.RaceResults = append(result.RaceResults, xrr.RaceResult{
+					RaceID:    races.id(group.FleetID, raceResult.RaceNumber),
+					Rank:      raceResult.Position,
+					Points:    raceResult.Points,
+					ScoreCode: raceResult.Code,
+					Discard:   raceResult.Discarded,
+				})
+			}
+			division.Results = append(division.Results, result)
+		}
+		if len(classes) == 1 {
+			for class := range classes {
+				division.BoatClass = class
+			}
+		}
+
+		for _, race := range races {
+			if race.FleetID == "" || race.FleetID == group.FleetID {
+				division.Races = append(division.Races, race.Race)
+			}
+		}
+		doc.Event.Divisions = append(doc.Event.Divisions, division)
+	}
+
+	return doc, nil
+}
+
+// xrrRace is a race of the regatta with the fleet that sails it.
+type xrrRace struct {
+	xrr.Race
+	FleetID string
+}
+
+type xrrRaceList []xrrRace
+
+// id returns the ID of the race with the given number sailed by a fleet.
+func (list xrrRaceList) id(fleetId string, raceNumber int) string {
+	for _, race := range list {
+		if race.RaceNumber == raceNumber && (race.FleetID == "" || race.FleetID == fleetId) {
+			return race.RaceID
+		}
+	}
+	return strconv.Itoa(raceNumber)
+}
+
+// xrrRaces reads the races of a regatta in race order.
+func xrrRaces(regattaId string) (xrrRaceList, error) {
+	stored, err := repo.ListRaces(regattaId)
+	if err != nil {
+		return nil, err
+	}
+
+	var races xrrRaceList
+	for _, race := range stored {
+		entry := xrrRace{
+			Race: xrr.Race{
+				RaceID:     race.ID,
+				RaceNumber: race.RaceNumber,
+				RaceName:   fmt.Sprintf("R%d", race.RaceNumber),
+				RaceStatus: string(race.Status),
+			},
+			FleetID: race.FleetID,
+		}
+		if race.StartTime != nil {
+			entry.Start = race.StartTime.UTC().Format(time.RFC3339)
+		}
+		races = append(races, entry)
+	}
+	return races, nil
+}
+
+// splitName splits a full name into given names and the family name, taken
+// to be the last word.
+func splitName(name string) (string, string) {
+	fields := strings.Fields(name)
+	if len(fields) < 2 {
+		return "", name
+	}
+	return strings.Join(fields[:len(fields)-1], " "), fields[len(fields)-1]
+}
@@ -0,0 +1,83 @@
+// Command migrate applies, rolls back and reports the schema migrations of
+// the database at DATABASE_URL, using the DATABASE_DRIVER backend.
+//
+//	go run ./migrate status
+//	go run ./migrate apply [version]
+//	go run ./migrate rollback [steps]
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"regatta-project/pkg/db"
+)
+
+func main() {
+	if len(os.Args) < 2 || len(os.Args) > 3 {
+		usage()
+	}
+
+	// An optional number: the version to apply up to, or the steps to roll back
+	number := 0
+	if len(os.Args) == 3 {
+		var err error
+		number, err = strconv.Atoi(os.Args[2])
+		if err != nil {
+			usage()
+		}
+	}
+
+	if err := db.Connect(); err != nil {
+		log.Fatal(err)
+	}
+	defer db.DB.Close()
+
+	switch os.Args[1] {
+	case "apply":
+		if number == 0 {
+			number = db.LatestVersion()
+		}
+		applied, err := db.MigrateUp(number)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Applied %d migrations\n", len(applied))
+	case "rollback":
+		if number == 0 {
+			number = 1
+		}
+		rolledBack, err := db.MigrateDown(number)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Rolled back %d migrations\n", len(rolledBack))
+	case "status":
+	default:
+		usage()
+	}
+
+	printStatus()
+}
+
+// printStatus lists every migration and whether it is applied.
+func printStatus() {
+	states, err := db.MigrationStatus()
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, state := range states {
+		applied := "pending"
+		if state.AppliedAt != nil {
+			applied = "applied " + state.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%3d  %-28s %s\n", state.Version, state.Name, applied)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate status | apply [version] | rollback [steps]")
+	os.Exit(2)
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Backend is a storage backend for the regatta data: how to open it and the
+// schema migrations in its SQL dialect. Queries throughout the project are
+// written for PostgreSQL with $N placeholders; a backend runs them as they
+// are or translates them.
+type Backend interface {
+	// Name selects the backend in DATABASE_DRIVER
+	Name() string
+	// Open connects to a database, DATABASE_URL or the backend's default
+	// when that is empty
+	Open(dsn string) (*sql.DB, error)
+	// Migrations lists the backend's schema migrations in version order
+	Migrations() []Migration
+}
+
+// backends are the storage backends that can be selected.
+var backends = map[string]Backend{}
+
+// backend is the backend of the open database.
+var backend Backend = postgresBackend{}
+
+func register(b Backend) {
+	backends[b.Name()] = b
+}
+
+// lookupBackend finds a backend by name; empty selects PostgreSQL.
+func lookupBackend(name string) (Backend, error) {
+	if name == "" {
+		return postgresBackend{}, nil
+	}
+	b, ok := backends[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(backends))
+		for name := range backends {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown DATABASE_DRIVER %q; use one of %s", name, strings.Join(names, ", "))
+	}
+	return b, nil
+}
@@ -0,0 +1,390 @@
+package db
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The conformance checks hold every storage backend to the same behaviour
+// for the query shapes the project uses. They always run on SQLite, and on
+// PostgreSQL when DATABASE_URL names an empty database.
+
+func TestSQLiteConformance(t *testing.T) {
+	runConformance(t, sqliteBackend{}, filepath.Join(t.TempDir(), "conformance.db"))
+}
+
+func TestPostgresConformance(t *testing.T) {
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+	runConformance(t, postgresBackend{}, url)
+}
+
+// conformanceChecks run in order and build on each other's rows, which
+// cleanupConformance removes at the end.
+var conformanceChecks = []struct {
+	name string
+	run  func(t *testing.T)
+}{
+	{"migrations apply", checkMigrationsApply},
+	{"migrations roll back", checkMigrationsRollBack},
+	{"placeholders bind by number", checkPlaceholders},
+	{"timestamps round trip and compare", checkTimestamps},
+	{"null timestamps scan as invalid", checkNullTimestamps},
+	{"fractional points are kept", checkFractionalPoints},
+	{"upserts update the conflicting row", checkUpserts},
+	{"duplicate results stop the unique index", checkDuplicateResultsStopMigration},
+	{"next version counts from zero", checkNextVersion},
+	{"booleans", checkBooleans},
+	{"unique constraints are enforced", checkUniqueConstraints},
+	{"foreign keys are enforced", checkForeignKeys},
+	{"transactions roll back", checkTransactions},
+}
+
+// runConformance opens a database of a backend, which must be empty, and
+// runs the checks on it. The database is left migrated and empty.
+func runConformance(t *testing.T, b Backend, dsn string) {
+	previousBackend, previousDB := backend, DB
+	if err := open(b, dsn); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		DB.Close()
+		backend, DB = previousBackend, previousDB
+	})
+
+	version, err := CurrentVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DB.Exec("SELECT 1 FROM regattas"); version > 0 || err == nil {
+		t.Skip("conformance checks need an empty database")
+	}
+
+	t.Cleanup(func() {
+		if err := cleanupConformance(); err != nil {
+			t.Errorf("cleaning up: %v", err)
+		}
+	})
+	for _, check := range conformanceChecks {
+		if !t.Run(check.name, check.run) {
+			// Later checks rely on the rows of earlier ones
+			t.FailNow()
+		}
+	}
+}
+
+func checkMigrationsApply(t *testing.T) {
+	if err := Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	expectVersion(t, LatestVersion())
+}
+
+func checkMigrationsRollBack(t *testing.T) {
+	if _, err := MigrateDown(LatestVersion()); err != nil {
+		t.Fatal(err)
+	}
+	expectVersion(t, 0)
+	if _, err := DB.Exec("SELECT 1 FROM regattas"); err == nil {
+		t.Fatal("regattas table still exists after rolling back every migration")
+	}
+	checkMigrationsApply(t)
+}
+
+func expectVersion(t *testing.T, want int) {
+	t.Helper()
+	version, err := CurrentVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != want {
+		t.Fatalf("schema version is %d, want %d", version, want)
+	}
+}
+
+func checkPlaceholders(t *testing.T) {
+	_, err := DB.Exec("INSERT INTO regattas (id, name, start_date, end_date, location, status) VALUES ($1, $2, $3, $4, $5, $6)",
+		"conformance-regatta", "Conformance", "2024-06-01", "2024-06-02", "Harbour", "SCHEDULED")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Out of order, as in an UPDATE whose key comes first
+	if _, err := DB.Exec("UPDATE regattas SET name = $2 WHERE id = $1", "conformance-regatta", "Renamed"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Used twice, as in an optional filter, next to a quoted "$1" that is
+	// not a placeholder
+	var count int
+	var name string
+	err = DB.QueryRow("SELECT COUNT(*), MAX(name) FROM regattas WHERE ($1 = '' OR id = $1) AND name <> '$1' AND location = $2",
+		"conformance-regatta", "Harbour").Scan(&count, &name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || name != "Renamed" {
+		t.Fatalf("got %d regattas named %q, want 1 named \"Renamed\"", count, name)
+	}
+}
+
+func checkTimestamps(t *testing.T) {
+	created := time.Date(2024, 6, 1, 10, 30, 15, 250000000, time.UTC)
+	_, err := DB.Exec("INSERT INTO organisations (id, name, slug, created_at) VALUES ($1, $2, $3, $4)",
+		"conformance-organisation", "Conformance", "conformance", created)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var read time.Time
+	if err := DB.QueryRow("SELECT created_at FROM organisations WHERE id = $1", "conformance-organisation").Scan(&read); err != nil {
+		t.Fatal(err)
+	}
+	if !read.Equal(created) {
+		t.Fatalf("read back %v, want %v", read, created)
+	}
+
+	var count int
+	err = DB.QueryRow("SELECT COUNT(*) FROM organisations WHERE id = $1 AND created_at <= $2 AND created_at > $3",
+		"conformance-organisation", created, created.Add(-time.Second)).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatal("timestamp comparison did not match the stored time")
+	}
+
+	// An optional time is stored from a pointer, as publications do
+	deadline := created.Add(time.Hour)
+	for id, value := range map[string]*time.Time{"conformance-publication": &deadline, "conformance-final": nil} {
+		_, err := DB.Exec(`INSERT INTO publications (id, regatta_id, race_number, status, published_at, protest_deadline, content)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`, id, "conformance-regatta", 0, "PROVISIONAL", created, value, "[]")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var stored sql.NullTime
+	if err := DB.QueryRow("SELECT protest_deadline FROM publications WHERE id = $1", "conformance-publication").Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if !stored.Valid || !stored.Time.Equal(deadline) {
+		t.Fatalf("protest deadline read back as %v, want %v", stored, deadline)
+	}
+}
+
+func checkNullTimestamps(t *testing.T) {
+	_, err := DB.Exec("INSERT INTO races (id, regatta_id, race_number, status) VALUES ($1, $2, $3, $4)",
+		"conformance-race", "conformance-regatta", 1, "SCHEDULED")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var start sql.NullTime
+	if err := DB.QueryRow("SELECT start_time FROM races WHERE id = $1", "conformance-race").Scan(&start); err != nil {
+		t.Fatal(err)
+	}
+	if start.Valid {
+		t.Fatalf("unset start time read back as %v", start.Time)
+	}
+
+	var deadline sql.NullTime
+	if err := DB.QueryRow("SELECT protest_deadline FROM publications WHERE id = $1", "conformance-final").Scan(&deadline); err != nil {
+		t.Fatal(err)
+	}
+	if deadline.Valid {
+		t.Fatalf("nil protest deadline read back as %v", deadline.Time)
+	}
+}
+
+func checkFractionalPoints(t *testing.T) {
+	_, err := DB.Exec("INSERT INTO teams (id, regatta_id, name) VALUES ($1, $2, $3)", "conformance-team", "conformance-regatta", "Team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = DB.Exec("INSERT INTO race_results (id, regatta_id, team_id, race_number, position, points) VALUES ($1, $2, $3, $4, $5, $6)",
+		"conformance-result", "conformance-regatta", "conformance-team", 1, 2, 2.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var points float64
+	if err := DB.QueryRow("SELECT points FROM race_results WHERE id = $1", "conformance-result").Scan(&points); err != nil {
+		t.Fatal(err)
+	}
+	if points != 2.5 {
+		t.Fatalf("points read back as %v, want 2.5", points)
+	}
+}
+
+func checkUpserts(t *testing.T) {
+	upsert := `INSERT INTO race_results (id, regatta_id, team_id, race_number, position, points) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (regatta_id, team_id, race_number) DO UPDATE SET position = excluded.position, points = excluded.points`
+	if _, err := DB.Exec(upsert, "conformance-upsert", "conformance-regatta", "conformance-team", 1, 3, 3.0); err != nil {
+		t.Fatal(err)
+	}
+
+	var count, position int
+	err := DB.QueryRow("SELECT COUNT(*), MAX(position) FROM race_results WHERE team_id = $1", "conformance-team").Scan(&count, &position)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || position != 3 {
+		t.Fatalf("got %d results with position %d, want 1 with position 3", count, position)
+	}
+}
+
+func checkDuplicateResultsStopMigration(t *testing.T) {
+	// Roll back to before the unique index and store a second result
+	if _, err := MigrateDown(LatestVersion() - 13); err != nil {
+		t.Fatal(err)
+	}
+	_, err := DB.Exec("INSERT INTO race_results (id, regatta_id, team_id, race_number, position, points) VALUES ($1, $2, $3, $4, $5, $6)",
+		"conformance-duplicate", "conformance-regatta", "conformance-team", 1, 4, 4.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Migrate()
+	if err == nil || !strings.Contains(err.Error(), "race 1: results conformance-duplicate, conformance-result") {
+		t.Fatalf("got %v, want the duplicate results listed", err)
+	}
+	expectVersion(t, 13)
+
+	if _, err := DB.Exec("DELETE FROM race_results WHERE id = $1", "conformance-duplicate"); err != nil {
+		t.Fatal(err)
+	}
+	checkMigrationsApply(t)
+}
+
+func checkNextVersion(t *testing.T) {
+	next := func() int {
+		t.Helper()
+		var version int
+		err := DB.QueryRow("SELECT COALESCE(MAX(version), 0) + 1 FROM standings_versions WHERE regatta_id = $1", "conformance-regatta").Scan(&version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return version
+	}
+
+	if version := next(); version != 1 {
+		t.Fatalf("first version is %d, want 1", version)
+	}
+	_, err := DB.Exec("INSERT INTO standings_versions (regatta_id, version, created_at, standings) VALUES ($1, $2, $3, $4)",
+		"conformance-regatta", 1, time.Now().UTC(), "[]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version := next(); version != 2 {
+		t.Fatalf("second version is %d, want 2", version)
+	}
+}
+
+func checkBooleans(t *testing.T) {
+	_, err := DB.Exec("INSERT INTO protests (id, regatta_id, race_number, status, lodged_at) VALUES ($1, $2, $3, $4, $5)",
+		"conformance-protest", "conformance-regatta", 1, "LODGED", time.Now().UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = DB.Exec("INSERT INTO protest_changes (id, protest_id, result_id, team_id, race_number, kind, changed_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		"conformance-change", "conformance-protest", "conformance-result", "conformance-team", 1, "decision", time.Now().UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reversed bool
+	if err := DB.QueryRow("SELECT reversed FROM protest_changes WHERE id = $1", "conformance-change").Scan(&reversed); err != nil {
+		t.Fatal(err)
+	}
+	if reversed {
+		t.Fatal("reversed defaults to true")
+	}
+	if _, err := DB.Exec("UPDATE protest_changes SET reversed = TRUE WHERE id = $1", "conformance-change"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DB.QueryRow("SELECT reversed FROM protest_changes WHERE id = $1", "conformance-change").Scan(&reversed); err != nil {
+		t.Fatal(err)
+	}
+	if !reversed {
+		t.Fatal("reversed was not set")
+	}
+}
+
+func checkUniqueConstraints(t *testing.T) {
+	insert := "INSERT INTO organisations (id, name, slug, created_at) VALUES ($1, $2, $3, $4)"
+	if _, err := DB.Exec(insert, "conformance-duplicate", "Duplicate", "conformance", time.Now().UTC()); err == nil {
+		t.Fatal("a second organisation with the same slug was stored")
+	}
+
+	// Sail numbers are unique per fleet without regard to case
+	insert = "INSERT INTO teams (id, regatta_id, name, sail_number) VALUES ($1, $2, $3, $4)"
+	if _, err := DB.Exec(insert, "conformance-sail", "conformance-regatta", "Sail", "gbr 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DB.Exec(insert, "conformance-sail-copy", "conformance-regatta", "Copy", "GBR 1"); err == nil {
+		t.Fatal("a second team with the same sail number was stored")
+	}
+
+	// A team has one result per race
+	insert = "INSERT INTO race_results (id, regatta_id, team_id, race_number, position, points) VALUES ($1, $2, $3, $4, $5, $6)"
+	if _, err := DB.Exec(insert, "conformance-result-copy", "conformance-regatta", "conformance-team", 1, 4, 4.0); err == nil {
+		t.Fatal("a second result of a team in the same race was stored")
+	}
+}
+
+func checkForeignKeys(t *testing.T) {
+	_, err := DB.Exec("INSERT INTO teams (id, regatta_id, name) VALUES ($1, $2, $3)", "conformance-orphan", "no-such-regatta", "Orphan")
+	if err == nil {
+		t.Fatal("a team of a missing regatta was stored")
+	}
+}
+
+func checkTransactions(t *testing.T) {
+	tx, err := DB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tx.Exec("INSERT INTO fleets (id, regatta_id, name) VALUES ($1, $2, $3)", "conformance-fleet", "conformance-regatta", "Fleet")
+	if err != nil {
+		tx.Rollback()
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	if err := DB.QueryRow("SELECT COUNT(*) FROM fleets WHERE id = $1", "conformance-fleet").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatal("a rolled back insert was kept")
+	}
+}
+
+// cleanupConformance removes the rows the checks stored, children first.
+func cleanupConformance() error {
+	for _, statement := range []string{
+		"DELETE FROM protest_changes WHERE id LIKE 'conformance-%'",
+		"DELETE FROM protests WHERE id LIKE 'conformance-%'",
+		"DELETE FROM standings_versions WHERE regatta_id LIKE 'conformance-%'",
+		"DELETE FROM publications WHERE id LIKE 'conformance-%'",
+		"DELETE FROM race_results WHERE id LIKE 'conformance-%'",
+		"DELETE FROM teams WHERE id LIKE 'conformance-%'",
+		"DELETE FROM races WHERE id LIKE 'conformance-%'",
+		"DELETE FROM fleets WHERE id LIKE 'conformance-%'",
+		"DELETE FROM regattas WHERE id LIKE 'conformance-%'",
+		"DELETE FROM organisations WHERE id LIKE 'conformance-%'",
+	} {
+		if _, err := DB.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
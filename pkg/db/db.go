@@ -1,78 +1,51 @@
-package db
-
-import (
-	"database/sql"
-	"log"
-	"os"
-
-	_ "github.com/lib/pq"
-)
-
-var DB *sql.DB
-
-func InitDB() error {
-	// Get the DATABASE_URL from environment variables
-	databaseURL := os.Getenv("DATABASE_URL")
-
-	// Log the DATABASE_URL for debugging purposes
-	log.Printf("Connecting to database at: %s", databaseURL)
-
-	var err error
-	DB, err = sql.Open("postgres", databaseURL)
-	if err != nil {
-		log.Fatal("Error opening database: ", err)
-		return err
-	}
-
-	// Log a message indicating that the database connection was successful
-	if err = DB.Ping(); err != nil {
-		log.Fatal("Error connecting to the database: ", err)
-		return err
-	}
-	log.Println("Database connection established successfully.")
-
-	// Create tables if they don't exist
-	return createTables()
-}
-
-func createTables() error {
-	createTables := `
-	CREATE TABLE IF NOT EXISTS regattas (
-		id TEXT PRIMARY KEY,
-		name TEXT NOT NULL,
-		start_date TEXT NOT NULL,
-		end_date TEXT NOT NULL,
-		location TEXT NOT NULL,
-		status TEXT NOT NULL
-	);
-
-	CREATE TABLE IF NOT EXISTS teams (
-		id TEXT PRIMARY KEY,
-		regatta_id TEXT NOT NULL,
-		name TEXT NOT NULL,
-		FOREIGN KEY (regatta_id) REFERENCES regattas(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS races (
-		id TEXT PRIMARY KEY,
-		regatta_id TEXT NOT NULL,
-		start_time TIMESTAMP,
-		end_time TIMESTAMP,
-		status TEXT NOT NULL,
-		FOREIGN KEY (regatta_id) REFERENCES regattas(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS race_results (
-		id TEXT PRIMARY KEY,
-		regatta_id TEXT NOT NULL,
-		team_id TEXT NOT NULL,
-		race_number INTEGER NOT NULL,
-		position INTEGER NOT NULL,
-		points INTEGER NOT NULL,
-		FOREIGN KEY (regatta_id) REFERENCES regattas(id),
-		FOREIGN KEY (team_id) REFERENCES teams(id)
-	);`
-
-	_, err := DB.Exec(createTables)
-	return err
-}
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+)
+
+var DB *sql.DB
+
+// InitDB connects to the database and brings its schema up to date.
+func InitDB() error {
+	if err := Connect(); err != nil {
+		return err
+	}
+
+	// Apply pending schema migrations
+	return Migrate()
+}
+
+// Connect opens the database at DATABASE_URL without changing its schema.
+// DATABASE_DRIVER selects the storage backend: "postgres" (the default) or
+// "sqlite", for which DATABASE_URL is the database file.
+func Connect() error {
+	b, err := lookupBackend(os.Getenv("DATABASE_DRIVER"))
+	if err != nil {
+		return err
+	}
+	return open(b, os.Getenv("DATABASE_URL"))
+}
+
+// open connects to a database of a backend and makes it the open database.
+// The DSN is not logged, as it may hold a password.
+func open(b Backend, dsn string) error {
+	log.Printf("Connecting to %s database", b.Name())
+
+	conn, err := b.Open(dsn)
+	if err != nil {
+		return fmt.Errorf("opening %s database: %w", b.Name(), err)
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return fmt.Errorf("connecting to %s database: %w", b.Name(), err)
+	}
+
+	backend = b
+	DB = conn
+	log.Println("Database connection established successfully.")
+	return nil
+}
@@ -0,0 +1,539 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Migration is one numbered change to the schema. Up applies it and Down
+// reverts it. Up statements are written to be safe on databases created
+// before migrations existed, which already have some or all of the schema.
+// Check, when set, runs before Up and stops the migration with an error
+// describing stored data that Up cannot apply to.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+	Check   func(tx *sql.Tx) error
+}
+
+// MigrationState is a migration and when it was applied, if it was.
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// migrations lists every migration in version order, in PostgreSQL's
+// dialect; other backends translate them. Add new ones at the end; never
+// change one that has been released.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "initial schema",
+		Up: `
+	CREATE TABLE IF NOT EXISTS regattas (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		start_date TEXT NOT NULL,
+		end_date TEXT NOT NULL,
+		location TEXT NOT NULL,
+		status TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS teams (
+		id TEXT PRIMARY KEY,
+		regatta_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		FOREIGN KEY (regatta_id) REFERENCES regattas(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS races (
+		id TEXT PRIMARY KEY,
+		regatta_id TEXT NOT NULL,
+		start_time TIMESTAMP,
+		end_time TIMESTAMP,
+		status TEXT NOT NULL,
+		FOREIGN KEY (regatta_id) REFERENCES regattas(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS race_results (
+		id TEXT PRIMARY KEY,
+		regatta_id TEXT NOT NULL,
+		team_id TEXT NOT NULL,
+		race_number INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		points INTEGER NOT NULL,
+		FOREIGN KEY (regatta_id) REFERENCES regattas(id),
+		FOREIGN KEY (team_id) REFERENCES teams(id)
+	);`,
+		Down: `
+	DROP TABLE IF EXISTS race_results;
+	DROP TABLE IF EXISTS races;
+	DROP TABLE IF EXISTS teams;
+	DROP TABLE IF EXISTS regattas;`,
+	},
+	{
+		Version: 2,
+		Name:    "scoring codes and discards",
+		Up: `
+	ALTER TABLE race_results ALTER COLUMN points TYPE REAL;
+	ALTER TABLE race_results ADD COLUMN IF NOT EXISTS code TEXT NOT NULL DEFAULT '';
+	ALTER TABLE regattas ADD COLUMN IF NOT EXISTS discards TEXT NOT NULL DEFAULT '';`,
+		Down: `
+	ALTER TABLE regattas DROP COLUMN IF EXISTS discards;
+	ALTER TABLE race_results DROP COLUMN IF EXISTS code;
+	ALTER TABLE race_results ALTER COLUMN points TYPE INTEGER USING ROUND(points);`,
+	},
+	{
+		Version: 3,
+		Name:    "handicap racing",
+		Up: `
+	ALTER TABLE regattas ADD COLUMN IF NOT EXISTS handicap_system TEXT NOT NULL DEFAULT '';
+	ALTER TABLE teams ADD COLUMN IF NOT EXISTS rating REAL NOT NULL DEFAULT 0;
+	ALTER TABLE race_results ADD COLUMN IF NOT EXISTS elapsed_time REAL NOT NULL DEFAULT 0;
+	ALTER TABLE race_results ADD COLUMN IF NOT EXISTS corrected_time REAL NOT NULL DEFAULT 0;`,
+		Down: `
+	ALTER TABLE race_results DROP COLUMN IF EXISTS corrected_time;
+	ALTER TABLE race_results DROP COLUMN IF EXISTS elapsed_time;
+	ALTER TABLE teams DROP COLUMN IF EXISTS rating;
+	ALTER TABLE regattas DROP COLUMN IF EXISTS handicap_system;`,
+	},
+	{
+		Version: 4,
+		Name:    "race finishes",
+		Up: `
+	ALTER TABLE races ADD COLUMN IF NOT EXISTS race_number INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE races ADD COLUMN IF NOT EXISTS distance REAL NOT NULL DEFAULT 0;
+
+	CREATE TABLE IF NOT EXISTS race_finishes (
+		id TEXT PRIMARY KEY,
+		race_id TEXT NOT NULL,
+		team_id TEXT NOT NULL,
+		finish_time TIMESTAMP,
+		code TEXT NOT NULL DEFAULT '',
+		FOREIGN KEY (race_id) REFERENCES races(id),
+		FOREIGN KEY (team_id) REFERENCES teams(id)
+	);`,
+		Down: `
+	DROP TABLE IF EXISTS race_finishes;
+	ALTER TABLE races DROP COLUMN IF EXISTS distance;
+	ALTER TABLE races DROP COLUMN IF EXISTS race_number;`,
+	},
+	{
+		Version: 5,
+		Name:    "fleets",
+		Up: `
+	CREATE TABLE IF NOT EXISTS fleets (
+		id TEXT PRIMARY KEY,
+		regatta_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		FOREIGN KEY (regatta_id) REFERENCES regattas(id)
+	);
+
+	ALTER TABLE teams ADD COLUMN IF NOT EXISTS fleet_id TEXT NOT NULL DEFAULT '';
+	ALTER TABLE races ADD COLUMN IF NOT EXISTS fleet_id TEXT NOT NULL DEFAULT '';`,
+		Down: `
+	ALTER TABLE races DROP COLUMN IF EXISTS fleet_id;
+	ALTER TABLE teams DROP COLUMN IF EXISTS fleet_id;
+	DROP TABLE IF EXISTS fleets;`,
+	},
+	{
+		Version: 6,
+		Name:    "series",
+		Up: `
+	CREATE TABLE IF NOT EXISTS series (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		discards TEXT NOT NULL DEFAULT '',
+		match_by TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS series_regattas (
+		series_id TEXT NOT NULL,
+		regatta_id TEXT NOT NULL,
+		PRIMARY KEY (series_id, regatta_id),
+		FOREIGN KEY (series_id) REFERENCES series(id),
+		FOREIGN KEY (regatta_id) REFERENCES regattas(id)
+	);`,
+		Down: `
+	DROP TABLE IF EXISTS series_regattas;
+	DROP TABLE IF EXISTS series;`,
+	},
+	{
+		Version: 7,
+		Name:    "entry details",
+		Up: `
+	ALTER TABLE teams ADD COLUMN IF NOT EXISTS sail_number TEXT NOT NULL DEFAULT '';
+	ALTER TABLE teams ADD COLUMN IF NOT EXISTS boat_name TEXT NOT NULL DEFAULT '';
+	ALTER TABLE teams ADD COLUMN IF NOT EXISTS class TEXT NOT NULL DEFAULT '';
+	ALTER TABLE teams ADD COLUMN IF NOT EXISTS helm TEXT NOT NULL DEFAULT '';
+	ALTER TABLE teams ADD COLUMN IF NOT EXISTS crew TEXT NOT NULL DEFAULT '';
+	ALTER TABLE teams ADD COLUMN IF NOT EXISTS club TEXT NOT NULL DEFAULT '';
+	ALTER TABLE teams ADD COLUMN IF NOT EXISTS country TEXT NOT NULL DEFAULT '';`,
+		Down: `
+	ALTER TABLE teams DROP COLUMN IF EXISTS country;
+	ALTER TABLE teams DROP COLUMN IF EXISTS club;
+	ALTER TABLE teams DROP COLUMN IF EXISTS crew;
+	ALTER TABLE teams DROP COLUMN IF EXISTS helm;
+	ALTER TABLE teams DROP COLUMN IF EXISTS class;
+	ALTER TABLE teams DROP COLUMN IF EXISTS boat_name;
+	ALTER TABLE teams DROP COLUMN IF EXISTS sail_number;`,
+	},
+	{
+		Version: 8,
+		Name:    "protests and penalties",
+		Up: `
+	ALTER TABLE race_results ADD COLUMN IF NOT EXISTS penalty REAL NOT NULL DEFAULT 0;
+	ALTER TABLE race_results ADD COLUMN IF NOT EXISTS redress_mode TEXT NOT NULL DEFAULT '';
+	ALTER TABLE race_results ADD COLUMN IF NOT EXISTS redress_points REAL NOT NULL DEFAULT 0;
+	ALTER TABLE race_results ADD COLUMN IF NOT EXISTS penalties TEXT NOT NULL DEFAULT '';
+
+	CREATE TABLE IF NOT EXISTS protests (
+		id TEXT PRIMARY KEY,
+		regatta_id TEXT NOT NULL,
+		race_number INTEGER NOT NULL,
+		protestor_team_id TEXT NOT NULL DEFAULT '',
+		protestee_team_id TEXT NOT NULL DEFAULT '',
+		rule TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		decision TEXT NOT NULL DEFAULT '',
+		lodged_at TIMESTAMP NOT NULL,
+		decided_at TIMESTAMP,
+		FOREIGN KEY (regatta_id) REFERENCES regattas(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS protest_changes (
+		id TEXT PRIMARY KEY,
+		protest_id TEXT NOT NULL,
+		result_id TEXT NOT NULL,
+		team_id TEXT NOT NULL,
+		race_number INTEGER NOT NULL,
+		kind TEXT NOT NULL,
+		old_code TEXT NOT NULL DEFAULT '',
+		old_penalty REAL NOT NULL DEFAULT 0,
+		new_code TEXT NOT NULL DEFAULT '',
+		new_penalty REAL NOT NULL DEFAULT 0,
+		old_redress_mode TEXT NOT NULL DEFAULT '',
+		old_redress_points REAL NOT NULL DEFAULT 0,
+		new_redress_mode TEXT NOT NULL DEFAULT '',
+		new_redress_points REAL NOT NULL DEFAULT 0,
+		reversed BOOLEAN NOT NULL DEFAULT FALSE,
+		changed_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (protest_id) REFERENCES protests(id)
+	);`,
+		Down: `
+	DROP TABLE IF EXISTS protest_changes;
+	DROP TABLE IF EXISTS protests;
+	ALTER TABLE race_results DROP COLUMN IF EXISTS penalties;
+	ALTER TABLE race_results DROP COLUMN IF EXISTS redress_points;
+	ALTER TABLE race_results DROP COLUMN IF EXISTS redress_mode;
+	ALTER TABLE race_results DROP COLUMN IF EXISTS penalty;`,
+	},
+	{
+		Version: 9,
+		Name:    "results audit trail",
+		Up: `
+	CREATE TABLE IF NOT EXISTS standings_versions (
+		regatta_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		changed_by TEXT NOT NULL DEFAULT '',
+		reason TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL,
+		standings TEXT NOT NULL,
+		PRIMARY KEY (regatta_id, version),
+		FOREIGN KEY (regatta_id) REFERENCES regattas(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS result_changes (
+		id TEXT PRIMARY KEY,
+		regatta_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		result_id TEXT NOT NULL,
+		team_id TEXT NOT NULL,
+		race_number INTEGER NOT NULL,
+		action TEXT NOT NULL,
+		before_result TEXT NOT NULL DEFAULT '',
+		after_result TEXT NOT NULL DEFAULT '',
+		changed_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (regatta_id, version) REFERENCES standings_versions(regatta_id, version)
+	);`,
+		Down: `
+	DROP TABLE IF EXISTS result_changes;
+	DROP TABLE IF EXISTS standings_versions;`,
+	},
+	{
+		Version: 10,
+		Name:    "publishing",
+		Up: `
+	ALTER TABLE regattas ADD COLUMN IF NOT EXISTS protest_time_limit INTEGER NOT NULL DEFAULT 0;
+
+	CREATE TABLE IF NOT EXISTS publications (
+		id TEXT PRIMARY KEY,
+		regatta_id TEXT NOT NULL,
+		race_number INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		published_by TEXT NOT NULL DEFAULT '',
+		published_at TIMESTAMP NOT NULL,
+		protest_deadline TIMESTAMP,
+		content TEXT NOT NULL,
+		FOREIGN KEY (regatta_id) REFERENCES regattas(id)
+	);`,
+		Down: `
+	DROP TABLE IF EXISTS publications;
+	ALTER TABLE regattas DROP COLUMN IF EXISTS protest_time_limit;`,
+	},
+	{
+		Version: 11,
+		Name:    "users and sessions",
+		Up: `
+	CREATE TABLE IF NOT EXISTS users (
+		id TEXT PRIMARY KEY,
+		username TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL,
+		role TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS sessions (
+		token_hash TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);`,
+		Down: `
+	DROP TABLE IF EXISTS sessions;
+	DROP TABLE IF EXISTS users;`,
+	},
+	{
+		Version: 12,
+		Name:    "organisations",
+		Up: `
+	CREATE TABLE IF NOT EXISTS organisations (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		slug TEXT NOT NULL UNIQUE,
+		created_at TIMESTAMP NOT NULL
+	);
+
+	ALTER TABLE regattas ADD COLUMN IF NOT EXISTS organisation_id TEXT NOT NULL DEFAULT '';
+	ALTER TABLE series ADD COLUMN IF NOT EXISTS organisation_id TEXT NOT NULL DEFAULT '';
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS organisation_id TEXT NOT NULL DEFAULT '';`,
+		Down: `
+	ALTER TABLE users DROP COLUMN IF EXISTS organisation_id;
+	ALTER TABLE series DROP COLUMN IF EXISTS organisation_id;
+	ALTER TABLE regattas DROP COLUMN IF EXISTS organisation_id;
+	DROP TABLE IF EXISTS organisations;`,
+	},
+	{
+		Version: 13,
+		Name:    "unique sail numbers",
+		Up: `
+	CREATE UNIQUE INDEX IF NOT EXISTS teams_sail_number ON teams (regatta_id, fleet_id, UPPER(sail_number))
+		WHERE sail_number <> '';`,
+		Down: `
+	DROP INDEX IF EXISTS teams_sail_number;`,
+	},
+	{
+		Version: 14,
+		Name:    "one result per team and race",
+		Check:   checkDuplicateResults,
+		Up: `
+	CREATE UNIQUE INDEX IF NOT EXISTS race_results_team_race ON race_results (regatta_id, team_id, race_number);`,
+		Down: `
+	DROP INDEX IF EXISTS race_results_team_race;`,
+	},
+}
+
+// checkDuplicateResults lists every team with more than one result for a
+// race. Which of them is right cannot be told from the rows, so they are
+// left for someone to delete before the unique index is created.
+func checkDuplicateResults(tx *sql.Tx) error {
+	rows, err := tx.Query(`
+	SELECT r.regatta_id, r.team_id, r.race_number, r.id FROM race_results r
+	WHERE EXISTS (
+		SELECT 1 FROM race_results d
+		WHERE d.regatta_id = r.regatta_id AND d.team_id = r.team_id AND d.race_number = r.race_number AND d.id <> r.id
+	)
+	ORDER BY r.regatta_id, r.team_id, r.race_number, r.id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var duplicates []string
+	var last string
+	for rows.Next() {
+		var regattaID, teamID, id string
+		var raceNumber int
+		if err := rows.Scan(&regattaID, &teamID, &raceNumber, &id); err != nil {
+			return err
+		}
+		key := fmt.Sprintf("regatta %s, team %s, race %d: results", regattaID, teamID, raceNumber)
+		if key == last {
+			duplicates[len(duplicates)-1] += ", " + id
+			continue
+		}
+		duplicates = append(duplicates, key+" "+id)
+		last = key
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("teams have more than one result for a race; delete all but one of each and migrate again:\n%s",
+			strings.Join(duplicates, "\n"))
+	}
+	return nil
+}
+
+// LatestVersion is the schema version after every migration is applied.
+func LatestVersion() int {
+	migrations := backend.Migrations()
+	return migrations[len(migrations)-1].Version
+}
+
+// createVersionTable creates the table recording each applied migration.
+func createVersionTable() error {
+	_, err := DB.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	);`)
+	return err
+}
+
+// CurrentVersion returns the highest applied migration, 0 for none.
+func CurrentVersion() (int, error) {
+	if err := createVersionTable(); err != nil {
+		return 0, err
+	}
+	var version int
+	err := DB.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+	return version, err
+}
+
+// Migrate applies every pending migration.
+func Migrate() error {
+	_, err := MigrateUp(LatestVersion())
+	return err
+}
+
+// MigrateUp applies the pending migrations up to and including a version,
+// each in its own transaction, and returns those applied.
+func MigrateUp(target int) ([]Migration, error) {
+	if target < 0 || target > LatestVersion() {
+		return nil, fmt.Errorf("version must be between 0 and %d", LatestVersion())
+	}
+	current, err := CurrentVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range backend.Migrations() {
+		if migration.Version <= current || migration.Version > target {
+			continue
+		}
+		if err := runMigration(migration, migration.Up, migration.Check, "INSERT INTO schema_version (version, name, applied_at) VALUES ($1, $2, $3)",
+			migration.Version, migration.Name, time.Now().UTC()); err != nil {
+			return applied, err
+		}
+		log.Printf("Applied migration %d: %s", migration.Version, migration.Name)
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// MigrateDown rolls back the latest applied migrations, a number of steps,
+// and returns those rolled back.
+func MigrateDown(steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("steps must be at least 1")
+	}
+	current, err := CurrentVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := backend.Migrations()
+	var rolledBack []Migration
+	for i := len(migrations) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		migration := migrations[i]
+		if migration.Version > current {
+			continue
+		}
+		if err := runMigration(migration, migration.Down, nil, "DELETE FROM schema_version WHERE version = $1", migration.Version); err != nil {
+			return rolledBack, err
+		}
+		log.Printf("Rolled back migration %d: %s", migration.Version, migration.Name)
+		rolledBack = append(rolledBack, migration)
+	}
+	return rolledBack, nil
+}
+
+// runMigration runs the check and statements of a migration and records it
+// in schema_version in one transaction, so a failing migration leaves
+// nothing behind.
+func runMigration(migration Migration, statements string, check func(tx *sql.Tx) error, record string, args ...any) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if check != nil {
+		if err := check(tx); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+	}
+	if _, err := tx.Exec(statements); err != nil {
+		return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.Exec(record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MigrationStatus lists every migration with when it was applied.
+func MigrationStatus() ([]MigrationState, error) {
+	if err := createVersionTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := DB.Query("SELECT version, applied_at FROM schema_version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	migrations := backend.Migrations()
+	states := make([]MigrationState, len(migrations))
+	for i, migration := range migrations {
+		states[i].Migration = migration
+		if at, ok := applied[migration.Version]; ok {
+			states[i].AppliedAt = &at
+		}
+	}
+	return states, nil
+}
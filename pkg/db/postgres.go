@@ -0,0 +1,26 @@
+package db
+
+import (
+	"database/sql"
+
+	_ "github.com/lib/pq"
+)
+
+// postgresBackend stores the data in PostgreSQL, the default backend.
+type postgresBackend struct{}
+
+func init() {
+	register(postgresBackend{})
+}
+
+func (postgresBackend) Name() string {
+	return "postgres"
+}
+
+func (postgresBackend) Open(dsn string) (*sql.DB, error) {
+	return sql.Open("postgres", dsn)
+}
+
+func (postgresBackend) Migrations() []Migration {
+	return migrations
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteDriverName is the database/sql driver that runs the project's
+// PostgreSQL-style queries on SQLite.
+const sqliteDriverName = "regatta-sqlite3"
+
+// defaultSQLiteFile is the database file used when DATABASE_URL is empty.
+const defaultSQLiteFile = "regatta.db"
+
+// sqliteOptions make SQLite behave like PostgreSQL where the code relies
+// on it: foreign keys are enforced, and concurrent requests wait for each
+// other's writes rather than failing. Transactions take the write lock when
+// they begin, as one that read first could not upgrade its lock while
+// another writes.
+const sqliteOptions = "_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"
+
+// sqliteBackend stores the data in an embedded SQLite file, for venues
+// without a network.
+type sqliteBackend struct{}
+
+func init() {
+	sql.Register(sqliteDriverName, &sqliteDriver{})
+	register(sqliteBackend{})
+}
+
+func (sqliteBackend) Name() string {
+	return "sqlite"
+}
+
+func (sqliteBackend) Open(dsn string) (*sql.DB, error) {
+	if dsn == "" {
+		dsn = defaultSQLiteFile
+	}
+	if !strings.Contains(dsn, "?") {
+		dsn += "?" + sqliteOptions
+	}
+	return sql.Open(sqliteDriverName, dsn)
+}
+
+// Migrations translates the PostgreSQL migrations to SQLite. SQLite has no
+// ADD COLUMN IF NOT EXISTS, DROP COLUMN IF EXISTS or column type changes,
+// and needs none of them: its databases were all created by migrations, so
+// schema_version is always right. Without the change of race_results.points
+// to REAL the column keeps fractional points anyway, as SQLite stores a
+// value that is not a whole number as REAL in an INTEGER column.
+func (sqliteBackend) Migrations() []Migration {
+	translated := make([]Migration, len(migrations))
+	for i, migration := range migrations {
+		migration.Up = toSQLite(migration.Up)
+		migration.Down = toSQLite(migration.Down)
+		translated[i] = migration
+	}
+	return translated
+}
+
+var sqliteRewrites = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?m)^\s*ALTER TABLE \w+ ALTER COLUMN [^;]*;\n?`), ""},
+	{regexp.MustCompile(`ADD COLUMN IF NOT EXISTS`), "ADD COLUMN"},
+	{regexp.MustCompile(`DROP COLUMN IF EXISTS`), "DROP COLUMN"},
+}
+
+func toSQLite(statements string) string {
+	for _, rewrite := range sqliteRewrites {
+		statements = rewrite.pattern.ReplaceAllString(statements, rewrite.replacement)
+	}
+	return statements
+}
+
+// rebind turns PostgreSQL's $N placeholders into SQLite's ?N, which bind
+// the Nth argument however often and in whatever order they appear. Quoted
+// strings are left alone.
+func rebind(query string) string {
+	if !strings.Contains(query, "$") {
+		return query
+	}
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if c == '\'' {
+			quoted = !quoted
+		}
+		if c == '$' && !quoted && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9' {
+			c = '?'
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// sqliteDriver is the SQLite driver with its queries rebound.
+type sqliteDriver struct {
+	sqlite3.SQLiteDriver
+}
+
+func (d *sqliteDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+type sqliteConn struct {
+	*sqlite3.SQLiteConn
+}
+
+func (c *sqliteConn) Prepare(query string) (driver.Stmt, error) {
+	return c.SQLiteConn.Prepare(rebind(query))
+}
+
+func (c *sqliteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.SQLiteConn.PrepareContext(ctx, rebind(query))
+}
+
+func (c *sqliteConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	return c.SQLiteConn.Exec(rebind(query), args)
+}
+
+func (c *sqliteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.SQLiteConn.ExecContext(ctx, rebind(query), args)
+}
+
+func (c *sqliteConn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	return c.SQLiteConn.Query(rebind(query), args)
+}
+
+func (c *sqliteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.SQLiteConn.QueryContext(ctx, rebind(query), args)
+}
@@ -0,0 +1,110 @@
+package handicap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// System is a handicap rule used to turn elapsed times into corrected times.
+type System string
+
+const (
+	// Scratch racing: corrected time equals elapsed time.
+	Scratch System = ""
+	// PHRFTimeOnTime corrects with the time correction factor 650 / (550 + rating).
+	PHRFTimeOnTime System = "PHRF-TOT"
+	// PHRFTimeOnDistance subtracts rating seconds per nautical mile sailed.
+	PHRFTimeOnDistance System = "PHRF-TOD"
+	// Portsmouth is the RYA Portsmouth Yardstick: elapsed * 1000 / PN.
+	Portsmouth System = "PY"
+	// ORCGPH subtracts the ORC General Purpose Handicap (seconds per mile)
+	// times the distance sailed.
+	ORCGPH System = "ORC-GPH"
+)
+
+var systems = map[System]bool{
+	Scratch:            true,
+	PHRFTimeOnTime:     true,
+	PHRFTimeOnDistance: true,
+	Portsmouth:         true,
+	ORCGPH:             true,
+}
+
+// ParseSystem normalises and validates a handicap system name.
+func ParseSystem(s string) (System, error) {
+	system := System(strings.ToUpper(strings.TrimSpace(s)))
+	if !systems[system] {
+		return "", fmt.Errorf("unknown handicap system %q", s)
+	}
+	return system, nil
+}
+
+// UsesDistance reports whether the system needs the course distance.
+func (s System) UsesDistance() bool {
+	return s == PHRFTimeOnDistance || s == ORCGPH
+}
+
+// Corrected returns the corrected time for a boat with the given rating that
+// sailed distance nautical miles in elapsed. Corrected times are rounded to
+// the nearest second, as published results are.
+func (s System) Corrected(elapsed time.Duration, rating, distance float64) (time.Duration, error) {
+	var corrected time.Duration
+	switch s {
+	case Scratch:
+		corrected = elapsed
+	case PHRFTimeOnTime:
+		corrected = time.Duration(float64(elapsed) * 650 / (550 + rating))
+	case PHRFTimeOnDistance, ORCGPH:
+		if distance <= 0 {
+			return 0, fmt.Errorf("%s requires a course distance", s)
+		}
+		corrected = elapsed - time.Duration(rating*distance*float64(time.Second))
+	case Portsmouth:
+		if rating <= 0 {
+			return 0, fmt.Errorf("portsmouth number must be greater than zero")
+		}
+		corrected = time.Duration(float64(elapsed) * 1000 / rating)
+	default:
+		return 0, fmt.Errorf("unknown handicap system %q", s)
+	}
+	return corrected.Round(time.Second), nil
+}
+
+// Finish is a boat's elapsed time in a race together with its rating.
+type Finish struct {
+	TeamID    string
+	Rating    float64
+	Elapsed   time.Duration
+	Corrected time.Duration
+	Position  int
+}
+
+// Rank computes corrected times for finishes and assigns finishing places in
+// corrected-time order. Boats with equal corrected times share a place.
+func (s System) Rank(finishes []Finish, distance float64) ([]Finish, error) {
+	ranked := make([]Finish, len(finishes))
+	copy(ranked, finishes)
+
+	for i := range ranked {
+		corrected, err := s.Corrected(ranked[i].Elapsed, ranked[i].Rating, distance)
+		if err != nil {
+			return nil, fmt.Errorf("team %s: %v", ranked[i].TeamID, err)
+		}
+		ranked[i].Corrected = corrected
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Corrected < ranked[j].Corrected
+	})
+	for i := range ranked {
+		if i > 0 && ranked[i].Corrected == ranked[i-1].Corrected {
+			ranked[i].Position = ranked[i-1].Position
+		} else {
+			ranked[i].Position = i + 1
+		}
+	}
+
+	return ranked, nil
+}
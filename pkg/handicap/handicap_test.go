@@ -0,0 +1,113 @@
+package handicap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCorrected(t *testing.T) {
+	tests := []struct {
+		name     string
+		system   System
+		elapsed  time.Duration
+		rating   float64
+		distance float64
+		want     time.Duration
+		wantErr  bool
+	}{
+		{name: "scratch", system: Scratch, elapsed: time.Hour, want: time.Hour},
+		{name: "PHRF time on time at 100", system: PHRFTimeOnTime, elapsed: time.Hour, rating: 100, want: time.Hour},
+		{name: "PHRF time on time rounds to the second", system: PHRFTimeOnTime, elapsed: time.Hour, rating: 0, want: 4255 * time.Second},
+		{name: "PHRF time on distance", system: PHRFTimeOnDistance, elapsed: time.Hour, rating: 150, distance: 10, want: 2100 * time.Second},
+		{name: "PHRF time on distance needs a distance", system: PHRFTimeOnDistance, elapsed: time.Hour, rating: 150, wantErr: true},
+		{name: "Portsmouth at 1000", system: Portsmouth, elapsed: time.Hour, rating: 1000, want: time.Hour},
+		{name: "Portsmouth", system: Portsmouth, elapsed: time.Hour, rating: 1200, want: 50 * time.Minute},
+		{name: "Portsmouth needs a number", system: Portsmouth, elapsed: time.Hour, rating: 0, wantErr: true},
+		{name: "ORC GPH", system: ORCGPH, elapsed: time.Hour, rating: 600, distance: 5, want: 10 * time.Minute},
+		{name: "ORC GPH needs a distance", system: ORCGPH, elapsed: time.Hour, rating: 600, wantErr: true},
+		{name: "unknown system", system: "IRC", elapsed: time.Hour, rating: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.system.Corrected(tt.elapsed, tt.rating, tt.distance)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	finishes := []Finish{
+		{TeamID: "A", Rating: 1000, Elapsed: 3100 * time.Second},
+		{TeamID: "B", Rating: 1200, Elapsed: time.Hour},
+		{TeamID: "C", Rating: 1000, Elapsed: 3000 * time.Second},
+	}
+	want := []struct {
+		team      string
+		corrected time.Duration
+		position  int
+	}{
+		{"B", 3000 * time.Second, 1},
+		{"C", 3000 * time.Second, 1},
+		{"A", 3100 * time.Second, 3},
+	}
+
+	ranked, err := Portsmouth.Rank(finishes, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, finish := range ranked {
+		if finish.TeamID != want[i].team || finish.Corrected != want[i].corrected || finish.Position != want[i].position {
+			t.Errorf("place %d: got %s at %v placed %d, want %s at %v placed %d", i+1,
+				finish.TeamID, finish.Corrected, finish.Position, want[i].team, want[i].corrected, want[i].position)
+		}
+	}
+
+	if _, err := PHRFTimeOnDistance.Rank(finishes, 0); err == nil {
+		t.Error("ranking without a distance: want an error")
+	}
+}
+
+func TestParseSystem(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    System
+		wantErr bool
+	}{
+		{input: "", want: Scratch},
+		{input: " phrf-tot ", want: PHRFTimeOnTime},
+		{input: "PHRF-TOD", want: PHRFTimeOnDistance},
+		{input: "py", want: Portsmouth},
+		{input: "orc-gph", want: ORCGPH},
+		{input: "IRC", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSystem(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
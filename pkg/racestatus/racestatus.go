@@ -0,0 +1,58 @@
+package racestatus
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is the stage of a race in its lifecycle.
+type Status string
+
+const (
+	Scheduled  Status = "SCHEDULED"
+	Postponed  Status = "POSTPONED"
+	InSequence Status = "IN_SEQUENCE"
+	Racing     Status = "RACING"
+	Finished   Status = "FINISHED"
+	Abandoned  Status = "ABANDONED"
+)
+
+// transitions lists the statuses a race may move to from each status.
+// A race in sequence returns to scheduled after a general recall, and an
+// abandoned race is scheduled again to be resailed.
+var transitions = map[Status][]Status{
+	Scheduled:  {InSequence, Postponed, Abandoned},
+	Postponed:  {Scheduled, InSequence, Abandoned},
+	InSequence: {Racing, Scheduled, Postponed, Abandoned},
+	Racing:     {Finished, Abandoned},
+	Finished:   {Abandoned},
+	Abandoned:  {Scheduled},
+}
+
+// Parse normalises and validates a race status.
+func Parse(s string) (Status, error) {
+	status := Status(strings.ToUpper(strings.TrimSpace(s)))
+	if _, ok := transitions[status]; !ok {
+		return "", fmt.Errorf("unknown race status %q", s)
+	}
+	return status, nil
+}
+
+// CanTransition reports whether a race may move from one status to another.
+func CanTransition(from, to Status) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTransition returns an error when a race may not move from one status
+// to another.
+func CheckTransition(from, to Status) error {
+	if !CanTransition(from, to) {
+		return fmt.Errorf("race cannot move from %s to %s", from, to)
+	}
+	return nil
+}
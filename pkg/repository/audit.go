@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Actions recorded in the results history
+const (
+	ResultCreated  = "create"
+	ResultAmended  = "amend"
+	ResultDeleted  = "delete"
+	ResultDecision = "decision"
+)
+
+// ResultChange is one change to a race result: its values before and after,
+// and the version of the standings the change produced. Before is empty for
+// a created result and After for a deleted one.
+type ResultChange struct {
+	Version    int         `json:"version"`
+	ResultID   string      `json:"resultId"`
+	TeamID     string      `json:"teamId"`
+	RaceNumber int         `json:"raceNumber"`
+	Action     string      `json:"action"`
+	Before     *RaceResult `json:"before,omitempty"`
+	After      *RaceResult `json:"after,omitempty"`
+	ChangedBy  string      `json:"changedBy,omitempty"`
+	Reason     string      `json:"reason,omitempty"`
+	ChangedAt  time.Time   `json:"changedAt"`
+}
+
+// StandingsVersion is the standings of a regatta as they stood after a set
+// of result changes, numbered from 1 for each regatta. Standings holds them
+// as encoded when the version was recorded.
+type StandingsVersion struct {
+	Version   int             `json:"version"`
+	ChangedBy string          `json:"changedBy,omitempty"`
+	Reason    string          `json:"reason,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Standings json.RawMessage `json:"standings,omitempty"`
+}
+
+// standingsVersionColumns lists the standings_versions columns read by
+// scanStandingsVersion, in order.
+const standingsVersionColumns = "version, changed_by, reason, created_at, standings"
+
+// scanStandingsVersion reads a standings version selected with
+// standingsVersionColumns.
+func scanStandingsVersion(row interface{ Scan(...any) error }) (StandingsVersion, error) {
+	var version StandingsVersion
+	var standings string
+	err := row.Scan(&version.Version, &version.ChangedBy, &version.Reason, &version.CreatedAt, &standings)
+	if err != nil {
+		return version, err
+	}
+	version.Standings = json.RawMessage(standings)
+	return version, nil
+}
+
+// NextStandingsVersion returns the number the next standings version of a
+// regatta will take.
+func (r *Repository) NextStandingsVersion(regattaId string) (int, error) {
+	var version int
+	err := r.db.QueryRow("SELECT COALESCE(MAX(version), 0) + 1 FROM standings_versions WHERE regatta_id = $1", regattaId).Scan(&version)
+	return version, err
+}
+
+// LatestStandingsVersion returns the number of the latest standings version
+// of a regatta, or zero when none has been recorded.
+func (r *Repository) LatestStandingsVersion(regattaId string) (int, error) {
+	var version int
+	err := r.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM standings_versions WHERE regatta_id = $1", regattaId).Scan(&version)
+	return version, err
+}
+
+// InsertStandingsVersion stores a version of a regatta's standings.
+func (r *Repository) InsertStandingsVersion(regattaId string, version StandingsVersion) error {
+	_, err := r.db.Exec("INSERT INTO standings_versions (regatta_id, version, changed_by, reason, created_at, standings) VALUES ($1, $2, $3, $4, $5, $6)",
+		regattaId, version.Version, version.ChangedBy, version.Reason, version.CreatedAt, string(version.Standings))
+	return err
+}
+
+// InsertResultChange stores a change recorded in a standings version.
+func (r *Repository) InsertResultChange(regattaId string, change ResultChange) error {
+	var before, after string
+	if change.Before != nil {
+		data, _ := json.Marshal(change.Before)
+		before = string(data)
+	}
+	if change.After != nil {
+		data, _ := json.Marshal(change.After)
+		after = string(data)
+	}
+
+	_, err := r.db.Exec(`INSERT INTO result_changes (id, regatta_id, version, result_id, team_id, race_number, action, before_result, after_result, changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		uuid.New().String(), regattaId, change.Version, change.ResultID, change.TeamID, change.RaceNumber, change.Action, before, after, change.ChangedAt)
+	return err
+}
+
+// ListResultChanges returns the recorded changes to the results of a
+// regatta, oldest first. A teamId or raceNumber narrows the list; zero
+// values match every team and race.
+func (r *Repository) ListResultChanges(regattaId, teamId string, raceNumber int) ([]ResultChange, error) {
+	rows, err := r.db.Query(`SELECT c.version, c.result_id, c.team_id, c.race_number, c.action, c.before_result, c.after_result, v.changed_by, v.reason, c.changed_at
+		FROM result_changes c
+		JOIN standings_versions v ON v.regatta_id = c.regatta_id AND v.version = c.version
+		WHERE c.regatta_id = $1 AND ($2 = '' OR c.team_id = $2) AND ($3 = 0 OR c.race_number = $3)
+		ORDER BY c.version, c.changed_at`, regattaId, teamId, raceNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]ResultChange, 0)
+	for rows.Next() {
+		var change ResultChange
+		var before, after string
+		if err := rows.Scan(&change.Version, &change.ResultID, &change.TeamID, &change.RaceNumber, &change.Action,
+			&before, &after, &change.ChangedBy, &change.Reason, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		change.Before = parseResultSnapshot(before)
+		change.After = parseResultSnapshot(after)
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// parseResultSnapshot decodes a result stored in the history.
+func parseResultSnapshot(s string) *RaceResult {
+	if s == "" {
+		return nil
+	}
+	var result RaceResult
+	if err := json.Unmarshal([]byte(s), &result); err != nil {
+		log.Printf("Invalid result snapshot %q: %v", s, err)
+		return nil
+	}
+	return &result
+}
+
+// ListStandingsVersions returns the versions of a regatta's standings
+// without their contents, oldest first.
+func (r *Repository) ListStandingsVersions(regattaId string) ([]StandingsVersion, error) {
+	rows, err := r.db.Query("SELECT version, changed_by, reason, created_at FROM standings_versions WHERE regatta_id = $1 ORDER BY version", regattaId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make([]StandingsVersion, 0)
+	for rows.Next() {
+		var version StandingsVersion
+		if err := rows.Scan(&version.Version, &version.ChangedBy, &version.Reason, &version.CreatedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+// GetStandingsVersion reads a version of a regatta's standings.
+func (r *Repository) GetStandingsVersion(regattaId string, number int) (StandingsVersion, error) {
+	version, err := scanStandingsVersion(r.db.QueryRow("SELECT "+standingsVersionColumns+" FROM standings_versions WHERE regatta_id = $1 AND version = $2",
+		regattaId, number))
+	if err == sql.ErrNoRows {
+		return version, NotFound("Standings version not found")
+	}
+	return version, err
+}
+
+// StandingsVersionAt reads the version of a regatta's standings that was
+// current at a time.
+func (r *Repository) StandingsVersionAt(regattaId string, at time.Time) (StandingsVersion, error) {
+	version, err := scanStandingsVersion(r.db.QueryRow("SELECT "+standingsVersionColumns+" FROM standings_versions WHERE regatta_id = $1 AND created_at <= $2 ORDER BY version DESC LIMIT 1",
+		regattaId, at))
+	if err == sql.ErrNoRows {
+		return version, NotFound("No standings had been recorded at that time")
+	}
+	return version, err
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// The kinds of error the repository reports. Test for them with errors.Is;
+// any other error is a failure of the database itself.
+var (
+	// ErrNotFound is a record that does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is a change that clashes with stored data, such as a
+	// sail number already used in the fleet
+	ErrConflict = errors.New("conflict")
+	// ErrValidation is input that can never be stored as given
+	ErrValidation = errors.New("validation failed")
+)
+
+// Error is an error of one of the kinds above with a message for the user.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// NotFound returns an ErrNotFound error.
+func NotFound(format string, args ...any) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// Conflict returns an ErrConflict error.
+func Conflict(format string, args ...any) error {
+	return &Error{Kind: ErrConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+// Validation returns an ErrValidation error.
+func Validation(format string, args ...any) error {
+	return &Error{Kind: ErrValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+// isForeignKeyViolation reports whether a statement failed because other
+// rows still refer to the row it changed, on either storage backend.
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23503"
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey
+	}
+	return false
+}
+
+// isUniqueViolation reports whether a statement failed because it would
+// store a value a unique index already holds, on either storage backend.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+	return false
+}
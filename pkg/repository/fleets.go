@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type Fleet struct {
+	ID        string `json:"id"`
+	RegattaID string `json:"regattaId"`
+	Name      string `json:"name"`
+}
+
+// validate trims a fleet's name and checks it is given.
+func (fleet *Fleet) validate() error {
+	fleet.Name = strings.TrimSpace(fleet.Name)
+	if fleet.Name == "" {
+		return Validation("name is required")
+	}
+	return nil
+}
+
+// ListFleets returns the fleets of a regatta ordered by name.
+func (r *Repository) ListFleets(regattaId string) ([]Fleet, error) {
+	rows, err := r.db.Query("SELECT id, regatta_id, name FROM fleets WHERE regatta_id = $1 ORDER BY name", regattaId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fleets := make([]Fleet, 0)
+	for rows.Next() {
+		var fleet Fleet
+		if err := rows.Scan(&fleet.ID, &fleet.RegattaID, &fleet.Name); err != nil {
+			return nil, err
+		}
+		fleets = append(fleets, fleet)
+	}
+	return fleets, rows.Err()
+}
+
+// CreateFleet validates and stores a new fleet of its regatta, giving it an
+// ID.
+func (r *Repository) CreateFleet(fleet *Fleet) error {
+	if err := fleet.validate(); err != nil {
+		return err
+	}
+
+	fleet.ID = uuid.New().String()
+	_, err := r.db.Exec("INSERT INTO fleets(id, regatta_id, name) VALUES($1, $2, $3)",
+		fleet.ID, fleet.RegattaID, fleet.Name)
+	return err
+}
+
+// InsertFleet stores a fleet as it is, for imports.
+func (r *Repository) InsertFleet(fleet Fleet) error {
+	_, err := r.db.Exec("INSERT INTO fleets(id, regatta_id, name) VALUES($1, $2, $3)", fleet.ID, fleet.RegattaID, fleet.Name)
+	return err
+}
+
+// UpdateFleet validates and stores the name of an existing fleet.
+func (r *Repository) UpdateFleet(fleet *Fleet) error {
+	if err := fleet.validate(); err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec("UPDATE fleets SET name = $1 WHERE id = $2 AND regatta_id = $3",
+		fleet.Name, fleet.ID, fleet.RegattaID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, "Fleet not found or doesn't belong to this regatta")
+}
+
+// DeleteFleet removes a fleet of a regatta. One that teams or races still
+// refer to is kept and reported as a conflict.
+func (r *Repository) DeleteFleet(regattaId, fleetId string) error {
+	exists, err := r.FleetExists(regattaId, fleetId)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return NotFound("Fleet not found or doesn't belong to this regatta")
+	}
+
+	var count int
+	err = r.db.QueryRow("SELECT (SELECT COUNT(*) FROM teams WHERE fleet_id = $1) + (SELECT COUNT(*) FROM races WHERE fleet_id = $1)", fleetId).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return Conflict("Fleet still has teams or races")
+	}
+
+	_, err = r.db.Exec("DELETE FROM fleets WHERE id = $1 AND regatta_id = $2", fleetId, regattaId)
+	return err
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organisation is a club sharing the deployment. It owns its regattas,
+// series and users; signed in users only see their own organisation's data.
+type Organisation struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// normalize trims an organisation's name and derives its slug from the
+// name when none is given.
+func (o *Organisation) normalize() error {
+	o.Name = strings.TrimSpace(o.Name)
+	if o.Name == "" {
+		return Validation("name is required")
+	}
+	o.Slug = strings.ToLower(strings.TrimSpace(o.Slug))
+	if o.Slug == "" {
+		o.Slug = strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(o.Name), "-"), "-")
+	}
+	if !slugPattern.MatchString(o.Slug) {
+		return Validation("slug must be lowercase letters and digits separated by hyphens")
+	}
+	return nil
+}
+
+// checkOrganisation normalizes an organisation and checks no other one
+// uses its slug.
+func (r *Repository) checkOrganisation(o *Organisation) error {
+	if err := o.normalize(); err != nil {
+		return err
+	}
+
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM organisations WHERE slug = $1 AND id != $2", o.Slug, o.ID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return Conflict("Slug is already used by another organisation")
+	}
+	return nil
+}
+
+// ListOrganisations returns every organisation ordered by name.
+func (r *Repository) ListOrganisations() ([]Organisation, error) {
+	rows, err := r.db.Query("SELECT id, name, slug, created_at FROM organisations ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	organisations := make([]Organisation, 0)
+	for rows.Next() {
+		var organisation Organisation
+		if err := rows.Scan(&organisation.ID, &organisation.Name, &organisation.Slug, &organisation.CreatedAt); err != nil {
+			return nil, err
+		}
+		organisations = append(organisations, organisation)
+	}
+	return organisations, rows.Err()
+}
+
+// GetOrganisation reads an organisation by its ID or slug.
+func (r *Repository) GetOrganisation(key string) (Organisation, error) {
+	var organisation Organisation
+	err := r.db.QueryRow("SELECT id, name, slug, created_at FROM organisations WHERE id = $1 OR slug = $1", key).
+		Scan(&organisation.ID, &organisation.Name, &organisation.Slug, &organisation.CreatedAt)
+	if err == sql.ErrNoRows {
+		return organisation, NotFound("Organisation not found")
+	}
+	return organisation, err
+}
+
+// CreateOrganisation checks and stores a new organisation, giving it an ID.
+func (r *Repository) CreateOrganisation(o *Organisation) error {
+	o.ID = ""
+	if err := r.checkOrganisation(o); err != nil {
+		return err
+	}
+
+	o.ID = uuid.New().String()
+	o.CreatedAt = time.Now().UTC()
+	_, err := r.db.Exec("INSERT INTO organisations (id, name, slug, created_at) VALUES ($1, $2, $3, $4)",
+		o.ID, o.Name, o.Slug, o.CreatedAt)
+	return err
+}
+
+// UpdateOrganisation checks and stores the name and slug of an existing
+// organisation.
+func (r *Repository) UpdateOrganisation(o *Organisation) error {
+	if err := r.checkOrganisation(o); err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec("UPDATE organisations SET name = $1, slug = $2 WHERE id = $3", o.Name, o.Slug, o.ID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, "Organisation not found")
+}
+
+// RegattaOwner returns the organisation a regatta belongs to.
+func (r *Repository) RegattaOwner(regattaId string) (string, error) {
+	var owner string
+	err := r.db.QueryRow("SELECT organisation_id FROM regattas WHERE id = $1", regattaId).Scan(&owner)
+	if err == sql.ErrNoRows {
+		return "", NotFound("Regatta not found")
+	}
+	return owner, err
+}
+
+// SeriesOwner returns the organisation a series belongs to.
+func (r *Repository) SeriesOwner(seriesId string) (string, error) {
+	var owner string
+	err := r.db.QueryRow("SELECT organisation_id FROM series WHERE id = $1", seriesId).Scan(&owner)
+	if err == sql.ErrNoRows {
+		return "", NotFound("Series not found")
+	}
+	return owner, err
+}
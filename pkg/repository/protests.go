@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Protest statuses
+const (
+	ProtestLodged    = "LODGED"
+	ProtestWithdrawn = "WITHDRAWN"
+	ProtestDecided   = "DECIDED"
+)
+
+// Kinds of protest change
+const (
+	ChangeDecision = "decision"
+	ChangeReversal = "reversal"
+)
+
+// Protest is a protest or request for redress heard by the jury. A protest
+// lodged by the race committee or the jury has no protestor; a request for
+// redress has no protestee.
+type Protest struct {
+	ID          string     `json:"id"`
+	RegattaID   string     `json:"regattaId"`
+	RaceNumber  int        `json:"raceNumber"`
+	ProtestorID string     `json:"protestorId,omitempty"`
+	ProtesteeID string     `json:"protesteeId,omitempty"`
+	Rule        string     `json:"rule,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Status      string     `json:"status"`
+	Decision    string     `json:"decision,omitempty"`
+	LodgedAt    time.Time  `json:"lodgedAt"`
+	DecidedAt   *time.Time `json:"decidedAt,omitempty"`
+	// Every change the decisions on this protest made to race results
+	Changes []ProtestChange `json:"changes"`
+}
+
+// ProtestChange records the code, penalty and redress of a race result
+// before and after a decision changed it. Deciding a protest again first
+// reverses the changes of the previous decision; those reversals are
+// recorded too.
+type ProtestChange struct {
+	ID               string    `json:"id"`
+	ResultID         string    `json:"resultId"`
+	TeamID           string    `json:"teamId"`
+	RaceNumber       int       `json:"raceNumber"`
+	Kind             string    `json:"kind"`
+	OldCode          string    `json:"oldCode"`
+	OldPenalty       float64   `json:"oldPenalty"`
+	NewCode          string    `json:"newCode"`
+	NewPenalty       float64   `json:"newPenalty"`
+	OldRedressMode   string    `json:"oldRedressMode,omitempty"`
+	OldRedressPoints float64   `json:"oldRedressPoints,omitempty"`
+	NewRedressMode   string    `json:"newRedressMode,omitempty"`
+	NewRedressPoints float64   `json:"newRedressPoints,omitempty"`
+	Reversed         bool      `json:"reversed"`
+	ChangedAt        time.Time `json:"changedAt"`
+}
+
+// checkProtest normalises a protest and checks that its race exists and its
+// teams belong to the regatta.
+func (r *Repository) checkProtest(protest *Protest) error {
+	protest.ProtestorID = strings.TrimSpace(protest.ProtestorID)
+	protest.ProtesteeID = strings.TrimSpace(protest.ProtesteeID)
+	protest.Rule = strings.TrimSpace(protest.Rule)
+	protest.Description = strings.TrimSpace(protest.Description)
+
+	if protest.ProtestorID == "" && protest.ProtesteeID == "" {
+		return Validation("a protestor or a protestee is required")
+	}
+	if protest.ProtestorID != "" && protest.ProtestorID == protest.ProtesteeID {
+		return Validation("a team cannot protest itself")
+	}
+
+	var races int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM races WHERE regatta_id = $1 AND race_number = $2",
+		protest.RegattaID, protest.RaceNumber).Scan(&races); err != nil {
+		return err
+	}
+	if races == 0 {
+		return Validation("race %d not found in this regatta", protest.RaceNumber)
+	}
+
+	for _, teamId := range []string{protest.ProtestorID, protest.ProtesteeID} {
+		if teamId == "" {
+			continue
+		}
+		_, err := r.GetTeam(protest.RegattaID, teamId)
+		if errors.Is(err, ErrNotFound) {
+			return Validation("team %s doesn't belong to this regatta", teamId)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListProtests returns the protests of a regatta in the order they were
+// lodged, with their histories of changes.
+func (r *Repository) ListProtests(regattaId string) ([]Protest, error) {
+	rows, err := r.db.Query("SELECT id FROM protests WHERE regatta_id = $1 ORDER BY lodged_at", regattaId)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	protests := make([]Protest, 0, len(ids))
+	for _, id := range ids {
+		protest, err := r.GetProtest(regattaId, id)
+		if err != nil {
+			return nil, err
+		}
+		protests = append(protests, protest)
+	}
+	return protests, nil
+}
+
+// GetProtest reads a protest of a regatta with its history of changes.
+func (r *Repository) GetProtest(regattaId, protestId string) (Protest, error) {
+	var protest Protest
+	var decidedAt sql.NullTime
+	err := r.db.QueryRow(`SELECT id, regatta_id, race_number, protestor_team_id, protestee_team_id, rule, description, status, decision, lodged_at, decided_at
+		FROM protests WHERE id = $1 AND regatta_id = $2`, protestId, regattaId).
+		Scan(&protest.ID, &protest.RegattaID, &protest.RaceNumber, &protest.ProtestorID, &protest.ProtesteeID,
+			&protest.Rule, &protest.Description, &protest.Status, &protest.Decision, &protest.LodgedAt, &decidedAt)
+	if err == sql.ErrNoRows {
+		return protest, NotFound("Protest not found or doesn't belong to this regatta")
+	}
+	if err != nil {
+		return protest, err
+	}
+	if decidedAt.Valid {
+		protest.DecidedAt = &decidedAt.Time
+	}
+
+	rows, err := r.db.Query(`SELECT id, result_id, team_id, race_number, kind, old_code, old_penalty, new_code, new_penalty,
+		old_redress_mode, old_redress_points, new_redress_mode, new_redress_points, reversed, changed_at
+		FROM protest_changes WHERE protest_id = $1 ORDER BY changed_at, kind DESC`, protestId)
+	if err != nil {
+		return protest, err
+	}
+	defer rows.Close()
+
+	protest.Changes = make([]ProtestChange, 0)
+	for rows.Next() {
+		var change ProtestChange
+		if err := rows.Scan(&change.ID, &change.ResultID, &change.TeamID, &change.RaceNumber, &change.Kind,
+			&change.OldCode, &change.OldPenalty, &change.NewCode, &change.NewPenalty,
+			&change.OldRedressMode, &change.OldRedressPoints, &change.NewRedressMode, &change.NewRedressPoints, &change.Reversed, &change.ChangedAt); err != nil {
+			return protest, err
+		}
+		protest.Changes = append(protest.Changes, change)
+	}
+	return protest, rows.Err()
+}
+
+// CreateProtest checks and stores a new protest of its regatta, giving it
+// an ID and the LODGED status.
+func (r *Repository) CreateProtest(protest *Protest) error {
+	protest.Status = ProtestLodged
+	protest.Decision = ""
+	protest.LodgedAt = time.Now().UTC()
+	protest.DecidedAt = nil
+	protest.Changes = make([]ProtestChange, 0)
+
+	if err := r.checkProtest(protest); err != nil {
+		return err
+	}
+
+	protest.ID = uuid.New().String()
+	_, err := r.db.Exec(`INSERT INTO protests (id, regatta_id, race_number, protestor_team_id, protestee_team_id, rule, description, status, decision, lodged_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		protest.ID, protest.RegattaID, protest.RaceNumber, protest.ProtestorID, protest.ProtesteeID,
+		protest.Rule, protest.Description, protest.Status, protest.Decision, protest.LodgedAt)
+	return err
+}
+
+// UpdateProtest checks and stores the details and status of an existing
+// protest.
+func (r *Repository) UpdateProtest(protest *Protest) error {
+	if err := r.checkProtest(protest); err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(`UPDATE protests SET race_number = $1, protestor_team_id = $2, protestee_team_id = $3, rule = $4, description = $5, status = $6
+		WHERE id = $7 AND regatta_id = $8`,
+		protest.RaceNumber, protest.ProtestorID, protest.ProtesteeID, protest.Rule, protest.Description, protest.Status, protest.ID, protest.RegattaID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, "Protest not found or doesn't belong to this regatta")
+}
+
+// ReverseDecisions marks the changes earlier decisions on a protest made as
+// reversed.
+func (r *Repository) ReverseDecisions(protestId string) error {
+	_, err := r.db.Exec("UPDATE protest_changes SET reversed = TRUE WHERE protest_id = $1 AND kind = $2", protestId, ChangeDecision)
+	return err
+}
+
+// ApplyProtestChange writes a change to its race result and records it in
+// the protest's history.
+func (r *Repository) ApplyProtestChange(protestId string, change ProtestChange, changedAt time.Time) error {
+	_, err := r.db.Exec("UPDATE race_results SET code = $1, penalty = $2, redress_mode = $3, redress_points = $4 WHERE id = $5",
+		change.NewCode, change.NewPenalty, change.NewRedressMode, change.NewRedressPoints, change.ResultID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`INSERT INTO protest_changes (id, protest_id, result_id, team_id, race_number, kind, old_code, old_penalty, new_code, new_penalty,
+		old_redress_mode, old_redress_points, new_redress_mode, new_redress_points, changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+		uuid.New().String(), protestId, change.ResultID, change.TeamID, change.RaceNumber, change.Kind,
+		change.OldCode, change.OldPenalty, change.NewCode, change.NewPenalty,
+		change.OldRedressMode, change.OldRedressPoints, change.NewRedressMode, change.NewRedressPoints, changedAt)
+	return err
+}
+
+// DecidedResults returns the IDs of the results of a race whose code,
+// penalty and redress were set by a protest decision still in force.
+func (r *Repository) DecidedResults(regattaId string, raceNumber int) (map[string]bool, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT c.result_id FROM protest_changes c
+		JOIN protests p ON p.id = c.protest_id
+		WHERE p.regatta_id = $1 AND c.race_number = $2 AND c.kind = $3 AND c.reversed = FALSE`,
+		regattaId, raceNumber, ChangeDecision)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	decided := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		decided[id] = true
+	}
+	return decided, rows.Err()
+}
+
+// DecideProtest records the jury's decision on a protest.
+func (r *Repository) DecideProtest(protestId, decision string, decidedAt time.Time) error {
+	_, err := r.db.Exec("UPDATE protests SET status = $1, decision = $2, decided_at = $3 WHERE id = $4",
+		ProtestDecided, decision, decidedAt, protestId)
+	return err
+}
+
+// OpenProtests returns when each undecided protest of a regatta was
+// lodged, by race number.
+func (r *Repository) OpenProtests(regattaId string) (map[int][]time.Time, error) {
+	rows, err := r.db.Query("SELECT race_number, lodged_at FROM protests WHERE regatta_id = $1 AND status = $2",
+		regattaId, ProtestLodged)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	open := make(map[int][]time.Time)
+	for rows.Next() {
+		var raceNumber int
+		var lodgedAt time.Time
+		if err := rows.Scan(&raceNumber, &lodgedAt); err != nil {
+			return nil, err
+		}
+		open[raceNumber] = append(open[raceNumber], lodgedAt)
+	}
+	return open, rows.Err()
+}
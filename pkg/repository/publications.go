@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Publication is a snapshot of results posted on the official notice board:
+// the standings of the regatta, or the scored results of one race when
+// RaceNumber is set. Content holds what was posted, encoded as JSON.
+type Publication struct {
+	ID              string     `json:"id"`
+	RegattaID       string     `json:"regattaId"`
+	RaceNumber      int        `json:"raceNumber,omitempty"`
+	Status          string     `json:"status"`
+	PublishedBy     string     `json:"publishedBy,omitempty"`
+	PublishedAt     time.Time  `json:"publishedAt"`
+	ProtestDeadline *time.Time `json:"protestDeadline,omitempty"`
+	Content         string     `json:"-"`
+}
+
+// InsertPublication stores a publication.
+func (r *Repository) InsertPublication(publication Publication) error {
+	_, err := r.db.Exec(`INSERT INTO publications (id, regatta_id, race_number, status, published_by, published_at, protest_deadline, content)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		publication.ID, publication.RegattaID, publication.RaceNumber, publication.Status, publication.PublishedBy,
+		publication.PublishedAt, publication.ProtestDeadline, publication.Content)
+	return err
+}
+
+// ListPublications returns the publications of a regatta in publishing
+// order, as they were stored.
+func (r *Repository) ListPublications(regattaId string) ([]Publication, error) {
+	rows, err := r.db.Query(`SELECT id, regatta_id, race_number, status, published_by, published_at, protest_deadline, content
+		FROM publications WHERE regatta_id = $1 ORDER BY published_at`, regattaId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	publications := make([]Publication, 0)
+	for rows.Next() {
+		var publication Publication
+		var deadline sql.NullTime
+		if err := rows.Scan(&publication.ID, &publication.RegattaID, &publication.RaceNumber, &publication.Status,
+			&publication.PublishedBy, &publication.PublishedAt, &deadline, &publication.Content); err != nil {
+			return nil, err
+		}
+		if deadline.Valid {
+			publication.ProtestDeadline = &deadline.Time
+		}
+		publications = append(publications, publication)
+	}
+	return publications, rows.Err()
+}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"regatta-project/pkg/racestatus"
+
+	"github.com/google/uuid"
+)
+
+type Race struct {
+	ID         string            `json:"id"`
+	RegattaID  string            `json:"regattaId"`
+	RaceNumber int               `json:"raceNumber"`
+	FleetID    string            `json:"fleetId,omitempty"`
+	StartTime  *time.Time        `json:"startTime,omitempty"`
+	EndTime    *time.Time        `json:"endTime,omitempty"`
+	Distance   float64           `json:"distance,omitempty"`
+	Status     racestatus.Status `json:"status"`
+}
+
+// Finish is a boat's finish time in a race, with the elapsed time and
+// finishing position derived from the race start. Times are in seconds.
+type Finish struct {
+	TeamID        string     `json:"teamId"`
+	FinishTime    *time.Time `json:"finishTime,omitempty"`
+	Code          string     `json:"code,omitempty"`
+	ElapsedTime   float64    `json:"elapsedTime,omitempty"`
+	CorrectedTime float64    `json:"correctedTime,omitempty"`
+	Position      int        `json:"position"`
+}
+
+// raceColumns lists the races columns read by scanRace, in order.
+const raceColumns = "id, regatta_id, fleet_id, race_number, start_time, end_time, distance, status"
+
+// scanRace reads a race selected with raceColumns.
+func scanRace(row interface{ Scan(...any) error }) (Race, error) {
+	var race Race
+	var startTime, endTime sql.NullTime
+	err := row.Scan(&race.ID, &race.RegattaID, &race.FleetID, &race.RaceNumber, &startTime, &endTime, &race.Distance, &race.Status)
+	if err != nil {
+		return race, err
+	}
+
+	if startTime.Valid {
+		race.StartTime = &startTime.Time
+	}
+	if endTime.Valid {
+		race.EndTime = &endTime.Time
+	}
+	return race, nil
+}
+
+// validate checks a race's number and distance.
+func (race *Race) validate() error {
+	if race.RaceNumber < 1 {
+		return Validation("raceNumber must be 1 or greater")
+	}
+	if race.Distance < 0 {
+		return Validation("distance must not be negative")
+	}
+	return nil
+}
+
+// ListRaces returns the races of a regatta ordered by number.
+func (r *Repository) ListRaces(regattaId string) ([]Race, error) {
+	rows, err := r.db.Query("SELECT "+raceColumns+" FROM races WHERE regatta_id = $1 ORDER BY race_number", regattaId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	races := make([]Race, 0)
+	for rows.Next() {
+		race, err := scanRace(rows)
+		if err != nil {
+			return nil, err
+		}
+		races = append(races, race)
+	}
+	return races, rows.Err()
+}
+
+// GetRace reads a race of a regatta.
+func (r *Repository) GetRace(regattaId, raceId string) (Race, error) {
+	race, err := scanRace(r.db.QueryRow("SELECT "+raceColumns+" FROM races WHERE id = $1 AND regatta_id = $2", raceId, regattaId))
+	if err == sql.ErrNoRows {
+		return race, NotFound("Race not found or doesn't belong to this regatta")
+	}
+	return race, err
+}
+
+// RaceNumberTaken reports whether a race number is already used by another
+// race the fleet sails. Races without a fleet share their numbers with every
+// fleet.
+func (r *Repository) RaceNumberTaken(regattaId, fleetId string, raceNumber int, raceId string) (bool, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM races WHERE regatta_id = $1 AND race_number = $2 AND ($3 = '' OR fleet_id = '' OR fleet_id = $3) AND id <> $4",
+		regattaId, raceNumber, fleetId, raceId).Scan(&count)
+	return count > 0, err
+}
+
+// CreateRace checks and stores a new race of its regatta, giving it an ID
+// and the SCHEDULED status.
+func (r *Repository) CreateRace(race *Race) error {
+	if err := race.validate(); err != nil {
+		return err
+	}
+
+	exists, err := r.FleetExists(race.RegattaID, race.FleetID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return Validation("Fleet not found or doesn't belong to this regatta")
+	}
+
+	taken, err := r.RaceNumberTaken(race.RegattaID, race.FleetID, race.RaceNumber, "")
+	if err != nil {
+		return err
+	}
+	if taken {
+		return Conflict("A race with this number already exists in this regatta")
+	}
+
+	race.ID = uuid.New().String()
+	race.EndTime = nil
+	race.Status = racestatus.Scheduled
+
+	_, err = r.db.Exec("INSERT INTO races(id, regatta_id, fleet_id, race_number, start_time, distance, status) VALUES($1, $2, $3, $4, $5, $6, $7)",
+		race.ID, race.RegattaID, race.FleetID, race.RaceNumber, race.StartTime, race.Distance, race.Status)
+	return err
+}
+
+// InsertRace stores a race as it is, for imports that number their races
+// themselves.
+func (r *Repository) InsertRace(race Race) error {
+	_, err := r.db.Exec("INSERT INTO races(id, regatta_id, fleet_id, race_number, start_time, distance, status) VALUES($1, $2, $3, $4, $5, $6, $7)",
+		race.ID, race.RegattaID, race.FleetID, race.RaceNumber, race.StartTime, race.Distance, race.Status)
+	return err
+}
+
+// UpdateRace checks and stores the number, start time and distance of an
+// existing race.
+func (r *Repository) UpdateRace(race *Race) error {
+	if err := race.validate(); err != nil {
+		return err
+	}
+
+	taken, err := r.RaceNumberTaken(race.RegattaID, race.FleetID, race.RaceNumber, race.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return Conflict("A race with this number already exists in this regatta")
+	}
+
+	result, err := r.db.Exec("UPDATE races SET race_number = $1, start_time = $2, distance = $3 WHERE id = $4 AND regatta_id = $5",
+		race.RaceNumber, race.StartTime, race.Distance, race.ID, race.RegattaID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, "Race not found or doesn't belong to this regatta")
+}
+
+// SetRaceStatus stores the lifecycle status of a race.
+func (r *Repository) SetRaceStatus(raceId string, status racestatus.Status) error {
+	_, err := r.db.Exec("UPDATE races SET status = $1 WHERE id = $2", status, raceId)
+	return err
+}
+
+// SetRaceTimes stores the start and end times of a race; nil clears them.
+func (r *Repository) SetRaceTimes(raceId string, startTime, endTime *time.Time) error {
+	_, err := r.db.Exec("UPDATE races SET start_time = $1, end_time = $2 WHERE id = $3", startTime, endTime, raceId)
+	return err
+}
+
+// DeleteRace removes a race and its finishes. Its results are left to the
+// caller, which records their deletion.
+func (r *Repository) DeleteRace(raceId string) error {
+	if err := r.DeleteFinishes(raceId); err != nil {
+		return err
+	}
+	_, err := r.db.Exec("DELETE FROM races WHERE id = $1", raceId)
+	return err
+}
+
+// CheckRaceFinished verifies that a race with the given number, sailed by
+// the given fleet, exists in a regatta and has finished, so results may be
+// entered for it.
+func (r *Repository) CheckRaceFinished(regattaId, fleetId string, raceNumber int) error {
+	var status racestatus.Status
+	err := r.db.QueryRow("SELECT status FROM races WHERE regatta_id = $1 AND race_number = $2 AND (fleet_id = '' OR fleet_id = $3)",
+		regattaId, raceNumber, fleetId).Scan(&status)
+	if err == sql.ErrNoRows {
+		return Validation("race %d not found in this regatta", raceNumber)
+	}
+	if err != nil {
+		return err
+	}
+	if status != racestatus.Finished {
+		return Conflict("race %d is not finished", raceNumber)
+	}
+	return nil
+}
+
+// ListFinishes returns the recorded finishes of a race.
+func (r *Repository) ListFinishes(raceId string) ([]Finish, error) {
+	rows, err := r.db.Query("SELECT team_id, finish_time, code FROM race_finishes WHERE race_id = $1", raceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	finishes := make([]Finish, 0)
+	for rows.Next() {
+		var finish Finish
+		var finishTime sql.NullTime
+		if err := rows.Scan(&finish.TeamID, &finishTime, &finish.Code); err != nil {
+			return nil, err
+		}
+		if finishTime.Valid {
+			finish.FinishTime = &finishTime.Time
+		}
+		finishes = append(finishes, finish)
+	}
+	return finishes, rows.Err()
+}
+
+// RecordFinish stores a boat's finish in a race, replacing any finish
+// recorded for it before.
+func (r *Repository) RecordFinish(raceId string, finish Finish) error {
+	_, err := r.db.Exec("DELETE FROM race_finishes WHERE race_id = $1 AND team_id = $2", raceId, finish.TeamID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec("INSERT INTO race_finishes(id, race_id, team_id, finish_time, code) VALUES($1, $2, $3, $4, $5)",
+		uuid.New().String(), raceId, finish.TeamID, finish.FinishTime, finish.Code)
+	return err
+}
+
+// DeleteFinishes removes the recorded finishes of a race.
+func (r *Repository) DeleteFinishes(raceId string) error {
+	_, err := r.db.Exec("DELETE FROM race_finishes WHERE race_id = $1", raceId)
+	return err
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"database/sql"
+	"log"
+
+	"regatta-project/pkg/handicap"
+	"regatta-project/pkg/scoring"
+
+	"github.com/google/uuid"
+)
+
+type Regatta struct {
+	ID             string                  `json:"id"`
+	Name           string                  `json:"name"`
+	StartDate      string                  `json:"startDate"`
+	EndDate        string                  `json:"endDate"`
+	Location       string                  `json:"location"`
+	Status         string                  `json:"status"`
+	Discards       scoring.DiscardSchedule `json:"discards"`
+	HandicapSystem handicap.System         `json:"handicapSystem,omitempty"`
+	// Minutes after publication during which protests may be lodged; zero
+	// uses the default time limit
+	ProtestTimeLimit int `json:"protestTimeLimit,omitempty"`
+	// The club that owns the regatta
+	OrganisationID string `json:"organisationId,omitempty"`
+}
+
+// regattaColumns lists the regattas columns read by scanRegatta, in order.
+const regattaColumns = "id, name, start_date, end_date, location, status, discards, handicap_system, protest_time_limit, organisation_id"
+
+// scanRegatta reads a regatta selected with regattaColumns.
+func scanRegatta(row interface{ Scan(...any) error }) (Regatta, error) {
+	var regatta Regatta
+	var discards string
+	err := row.Scan(&regatta.ID, &regatta.Name, &regatta.StartDate, &regatta.EndDate, &regatta.Location, &regatta.Status,
+		&discards, &regatta.HandicapSystem, &regatta.ProtestTimeLimit, &regatta.OrganisationID)
+	if err != nil {
+		return regatta, err
+	}
+	regatta.Discards = ParseDiscards(discards)
+	return regatta, nil
+}
+
+// ParseDiscards decodes a discard schedule stored on a regatta or series row.
+func ParseDiscards(s string) scoring.DiscardSchedule {
+	schedule, err := scoring.ParseDiscardSchedule(s)
+	if err != nil {
+		log.Printf("Invalid discard schedule %q: %v", s, err)
+	}
+	return schedule
+}
+
+// validate checks a regatta's settings and normalizes its handicap system.
+func (regatta *Regatta) validate() error {
+	if err := regatta.Discards.Validate(); err != nil {
+		return Validation("%v", err)
+	}
+
+	system, err := handicap.ParseSystem(string(regatta.HandicapSystem))
+	if err != nil {
+		return Validation("%v", err)
+	}
+	regatta.HandicapSystem = system
+
+	if regatta.ProtestTimeLimit < 0 {
+		return Validation("protestTimeLimit must not be negative")
+	}
+	return nil
+}
+
+// ListRegattas returns the regattas of an organisation, or of every
+// organisation when organisationId is empty.
+func (r *Repository) ListRegattas(organisationId string) ([]Regatta, error) {
+	rows, err := r.db.Query("SELECT "+regattaColumns+" FROM regattas WHERE ($1 = '' OR organisation_id = $1)", organisationId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	regattas := make([]Regatta, 0)
+	for rows.Next() {
+		regatta, err := scanRegatta(rows)
+		if err != nil {
+			return nil, err
+		}
+		regattas = append(regattas, regatta)
+	}
+	return regattas, rows.Err()
+}
+
+// GetRegatta reads a regatta by ID.
+func (r *Repository) GetRegatta(id string) (Regatta, error) {
+	regatta, err := scanRegatta(r.db.QueryRow("SELECT "+regattaColumns+" FROM regattas WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return regatta, NotFound("Regatta not found")
+	}
+	return regatta, err
+}
+
+// CreateRegatta validates and stores a new regatta, giving it an ID and the
+// SCHEDULED status.
+func (r *Repository) CreateRegatta(regatta *Regatta) error {
+	if err := regatta.validate(); err != nil {
+		return err
+	}
+
+	regatta.ID = uuid.New().String()
+	regatta.Status = "SCHEDULED"
+
+	_, err := r.db.Exec("INSERT INTO regattas(id, name, start_date, end_date, location, status, discards, handicap_system, protest_time_limit, organisation_id) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		regatta.ID, regatta.Name, regatta.StartDate, regatta.EndDate, regatta.Location, regatta.Status,
+		regatta.Discards.String(), regatta.HandicapSystem, regatta.ProtestTimeLimit, regatta.OrganisationID)
+	return err
+}
+
+// InsertRegatta stores a regatta as it is, for imports that create it
+// together with its fleets, teams and races.
+func (r *Repository) InsertRegatta(regatta Regatta) error {
+	_, err := r.db.Exec("INSERT INTO regattas(id, name, start_date, end_date, location, status, discards, handicap_system, protest_time_limit, organisation_id) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		regatta.ID, regatta.Name, regatta.StartDate, regatta.EndDate, regatta.Location, regatta.Status,
+		regatta.Discards.String(), regatta.HandicapSystem, regatta.ProtestTimeLimit, regatta.OrganisationID)
+	return err
+}
+
+// LockRegatta holds a regatta until the transaction it is called in ends,
+// so that transactions changing its results run one after another.
+func (r *Repository) LockRegatta(regattaId string) error {
+	result, err := r.db.Exec("UPDATE regattas SET id = id WHERE id = $1", regattaId)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, "Regatta not found")
+}
+
+// UpdateRegatta validates and stores the settings of an existing regatta.
+// Its organisation cannot be changed and is filled in from the stored row.
+func (r *Repository) UpdateRegatta(regatta *Regatta) error {
+	if err := regatta.validate(); err != nil {
+		return err
+	}
+
+	err := r.db.QueryRow(`UPDATE regattas SET name = $1, start_date = $2, end_date = $3, location = $4, status = $5, discards = $6,
+		handicap_system = $7, protest_time_limit = $8 WHERE id = $9 RETURNING organisation_id`,
+		regatta.Name, regatta.StartDate, regatta.EndDate, regatta.Location, regatta.Status, regatta.Discards.String(),
+		regatta.HandicapSystem, regatta.ProtestTimeLimit, regatta.ID).Scan(&regatta.OrganisationID)
+	if err == sql.ErrNoRows {
+		return NotFound("Regatta not found")
+	}
+	return err
+}
+
+// DeleteRegatta removes a regatta. One that still has entries, races or
+// results, or belongs to a series, is kept and reported as a conflict.
+func (r *Repository) DeleteRegatta(id string) error {
+	result, err := r.db.Exec("DELETE FROM regattas WHERE id = $1", id)
+	if isForeignKeyViolation(err) {
+		return Conflict("Regatta still has entries, races or results, or belongs to a series")
+	}
+	if err != nil {
+		return err
+	}
+	return expectRow(result, "Regatta not found")
+}
+
+// expectRow reports a statement that changed no row as not found.
+func expectRow(result sql.Result, message string) error {
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return NotFound("%s", message)
+	}
+	return nil
+}
+
+// DashboardStats are the counts shown on the dashboard.
+type DashboardStats struct {
+	ActiveRegattas int `json:"activeRegattas"`
+	TotalTeams     int `json:"totalTeams"`
+	RacesCompleted int `json:"racesCompleted"`
+	UpcomingRaces  int `json:"upcomingRaces"`
+}
+
+// DashboardStats counts the regattas, teams and races of an organisation, or
+// of every organisation when organisationId is empty.
+func (r *Repository) DashboardStats(organisationId string) (DashboardStats, error) {
+	var stats DashboardStats
+
+	// Get active regattas count
+	err := r.db.QueryRow("SELECT COUNT(*) FROM regattas WHERE status = 'active' AND ($1 = '' OR organisation_id = $1)", organisationId).Scan(&stats.ActiveRegattas)
+	if err != nil {
+		return stats, err
+	}
+
+	// Get total teams count
+	err = r.db.QueryRow("SELECT COUNT(*) FROM teams t JOIN regattas g ON g.id = t.regatta_id WHERE ($1 = '' OR g.organisation_id = $1)", organisationId).Scan(&stats.TotalTeams)
+	if err != nil {
+		return stats, err
+	}
+
+	// Get completed races count
+	err = r.db.QueryRow(`SELECT COUNT(*) FROM (SELECT DISTINCT rr.regatta_id, rr.race_number FROM race_results rr
+		JOIN regattas g ON g.id = rr.regatta_id WHERE ($1 = '' OR g.organisation_id = $1)) AS races`, organisationId).Scan(&stats.RacesCompleted)
+	if err != nil {
+		return stats, err
+	}
+
+	// Get upcoming races count
+	err = r.db.QueryRow("SELECT COUNT(*) FROM regattas WHERE status = 'SCHEDULED' AND ($1 = '' OR organisation_id = $1)", organisationId).Scan(&stats.UpcomingRaces)
+	return stats, err
+}
@@ -0,0 +1,48 @@
+// Package repository reads and writes the regatta data. Its methods check
+// what they are given and report failures as typed errors, so callers can
+// tell a missing record, a clash with stored data and invalid input apart
+// from a failing database.
+package repository
+
+import (
+	"database/sql"
+)
+
+// queryer runs statements on the database or on an open transaction.
+type queryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// Repository stores regattas and their entries in a database opened by
+// package db, or in a transaction begun by Transaction.
+type Repository struct {
+	db queryer
+	// The database transactions are begun on; nil inside a transaction
+	conn *sql.DB
+}
+
+// New returns a repository on an open database.
+func New(db *sql.DB) *Repository {
+	return &Repository{db: db, conn: db}
+}
+
+// Transaction runs fn with a repository whose reads and writes all belong
+// to one transaction, committed when fn returns nil and rolled back when it
+// returns an error. Inside a transaction fn joins the one already open.
+func (r *Repository) Transaction(fn func(tx *Repository) error) error {
+	if r.conn == nil {
+		return fn(r)
+	}
+
+	tx, err := r.conn.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(&Repository{db: tx}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+
+	"regatta-project/pkg/scoring"
+
+	"github.com/google/uuid"
+)
+
+type RaceResult struct {
+	ID         string `json:"id"`
+	RegattaID  string `json:"regattaId"`
+	TeamID     string `json:"teamId"`
+	RaceNumber int    `json:"raceNumber"`
+	Position   int    `json:"position"`
+	Code       string `json:"code,omitempty"`
+	// Percentage of the DNF score added for a DPI
+	Penalty float64 `json:"penalty,omitempty"`
+	// How the points of an RDG are calculated, with the points of FIXED redress
+	RedressMode   string  `json:"redressMode,omitempty"`
+	RedressPoints float64 `json:"redressPoints,omitempty"`
+	// Scoring penalties such as SCP and ZFP stacked on top of the place
+	Penalties []scoring.ScoringPenalty `json:"penalties,omitempty"`
+	Points    float64                  `json:"points"`
+	Discarded bool                     `json:"discarded"`
+	// Elapsed and corrected times in seconds, set for handicap races
+	ElapsedTime   float64 `json:"elapsedTime,omitempty"`
+	CorrectedTime float64 `json:"correctedTime,omitempty"`
+	// Details of the team's entry, filled in when results are returned
+	EntryDetails
+}
+
+// Entry is what scoring needs to know about a team: its fleet and rating.
+type Entry struct {
+	FleetID string
+	Rating  float64
+}
+
+// resultColumns lists the race_results columns read by scanResult, in order.
+const resultColumns = "id, regatta_id, team_id, race_number, position, code, penalty, redress_mode, redress_points, penalties, points, elapsed_time, corrected_time"
+
+// inRaceFleet restricts a race_results query to the teams of a race's fleet,
+// passed as $3. A race without a fleet is sailed by every team.
+const inRaceFleet = "($3 = '' OR team_id IN (SELECT id FROM teams WHERE fleet_id = $3))"
+
+// scanResult reads a race result selected with resultColumns.
+func scanResult(row interface{ Scan(...any) error }) (RaceResult, error) {
+	var result RaceResult
+	var penalties string
+	err := row.Scan(&result.ID, &result.RegattaID, &result.TeamID, &result.RaceNumber, &result.Position, &result.Code,
+		&result.Penalty, &result.RedressMode, &result.RedressPoints, &penalties, &result.Points, &result.ElapsedTime, &result.CorrectedTime)
+	if err != nil {
+		return result, err
+	}
+	result.Penalties = parsePenalties(penalties)
+	return result, nil
+}
+
+// formatPenalties encodes scoring penalties for the penalties column.
+func formatPenalties(penalties []scoring.ScoringPenalty) string {
+	if len(penalties) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(penalties)
+	return string(data)
+}
+
+// parsePenalties decodes the penalties column.
+func parsePenalties(s string) []scoring.ScoringPenalty {
+	if s == "" {
+		return nil
+	}
+	var penalties []scoring.ScoringPenalty
+	if err := json.Unmarshal([]byte(s), &penalties); err != nil {
+		log.Printf("Invalid scoring penalties %q: %v", s, err)
+	}
+	return penalties
+}
+
+// queryResults reads the race results a query selects with resultColumns.
+func (r *Repository) queryResults(query string, args ...any) ([]RaceResult, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]RaceResult, 0)
+	for rows.Next() {
+		result, err := scanResult(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// Entries returns the fleet and handicap rating of every team in a regatta
+// by team ID.
+func (r *Repository) Entries(regattaId string) (map[string]Entry, error) {
+	rows, err := r.db.Query("SELECT id, fleet_id, rating FROM teams WHERE regatta_id = $1", regattaId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make(map[string]Entry)
+	for rows.Next() {
+		var id string
+		var e Entry
+		if err := rows.Scan(&id, &e.FleetID, &e.Rating); err != nil {
+			return nil, err
+		}
+		entries[id] = e
+	}
+	return entries, rows.Err()
+}
+
+// ListResults returns every race result of a regatta in race and finishing
+// order.
+func (r *Repository) ListResults(regattaId string) ([]RaceResult, error) {
+	return r.queryResults("SELECT "+resultColumns+" FROM race_results WHERE regatta_id = $1 ORDER BY race_number, position", regattaId)
+}
+
+// RaceResults returns the results of a race: those with its number of the
+// teams in its fleet, or of every team when fleetId is empty.
+func (r *Repository) RaceResults(regattaId string, raceNumber int, fleetId string) ([]RaceResult, error) {
+	return r.queryResults("SELECT "+resultColumns+" FROM race_results WHERE regatta_id = $1 AND race_number = $2 AND "+inRaceFleet,
+		regattaId, raceNumber, fleetId)
+}
+
+// GetResult reads a race result by ID.
+func (r *Repository) GetResult(id string) (RaceResult, error) {
+	result, err := scanResult(r.db.QueryRow("SELECT "+resultColumns+" FROM race_results WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return result, NotFound("Race result not found")
+	}
+	return result, err
+}
+
+// SaveResult stores a team's result of a race. A result the team already
+// has for the race is replaced in place, keeping its ID, and returned;
+// otherwise the new result is given an ID and nil is returned.
+func (r *Repository) SaveResult(result *RaceResult) (*RaceResult, error) {
+	existing, err := r.queryResults("SELECT "+resultColumns+" FROM race_results WHERE regatta_id = $1 AND team_id = $2 AND race_number = $3",
+		result.RegattaID, result.TeamID, result.RaceNumber)
+	if err != nil {
+		return nil, err
+	}
+	var before *RaceResult
+	if len(existing) > 0 {
+		before = &existing[0]
+		result.ID = before.ID
+	} else {
+		result.ID = uuid.New().String()
+	}
+
+	_, err = r.db.Exec(`INSERT INTO race_results (id, regatta_id, team_id, race_number, position, code, penalty, redress_mode, redress_points, penalties, points, elapsed_time, corrected_time)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (regatta_id, team_id, race_number) DO UPDATE SET position = excluded.position, code = excluded.code,
+			penalty = excluded.penalty, redress_mode = excluded.redress_mode, redress_points = excluded.redress_points,
+			penalties = excluded.penalties, points = excluded.points, elapsed_time = excluded.elapsed_time, corrected_time = excluded.corrected_time`,
+		result.ID, result.RegattaID, result.TeamID, result.RaceNumber, result.Position, result.Code, result.Penalty, result.RedressMode,
+		result.RedressPoints, formatPenalties(result.Penalties), result.Points, result.ElapsedTime, result.CorrectedTime)
+	return before, err
+}
+
+// SetResultPoints stores the points the scoring engine gave a result.
+func (r *Repository) SetResultPoints(id string, points float64) error {
+	_, err := r.db.Exec("UPDATE race_results SET points = $1 WHERE id = $2", points, id)
+	return err
+}
+
+// RenumberResults moves the results of a race to a new race number and
+// returns them as they were before.
+func (r *Repository) RenumberResults(regattaId string, from, to int, fleetId string) ([]RaceResult, error) {
+	results, err := r.RaceResults(regattaId, from, fleetId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.db.Exec("UPDATE race_results SET race_number = $4 WHERE regatta_id = $1 AND race_number = $2 AND "+inRaceFleet,
+		regattaId, from, fleetId, to)
+	return results, err
+}
+
+// DeleteRaceResults removes the results of a race and returns them.
+func (r *Repository) DeleteRaceResults(regattaId string, raceNumber int, fleetId string) ([]RaceResult, error) {
+	results, err := r.RaceResults(regattaId, raceNumber, fleetId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.db.Exec("DELETE FROM race_results WHERE regatta_id = $1 AND race_number = $2 AND "+inRaceFleet, regattaId, raceNumber, fleetId)
+	return results, err
+}
+
+// DeleteRegattaResults removes every result of a regatta and returns them.
+func (r *Repository) DeleteRegattaResults(regattaId string) ([]RaceResult, error) {
+	results, err := r.ListResults(regattaId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.db.Exec("DELETE FROM race_results WHERE regatta_id = $1", regattaId)
+	return results, err
+}
+
+// DeleteResult removes a race result and returns it.
+func (r *Repository) DeleteResult(id string) (RaceResult, error) {
+	result, err := r.GetResult(id)
+	if err != nil {
+		return result, err
+	}
+	_, err = r.db.Exec("DELETE FROM race_results WHERE id = $1", id)
+	return result, err
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"regatta-project/pkg/scoring"
+
+	"github.com/google/uuid"
+)
+
+// How teams of different regattas are recognised as the same boat
+const (
+	MatchBySailNumber = "sailNumber"
+	MatchByHelm       = "helm"
+)
+
+// Series groups regattas, such as a club championship, into one overall
+// ranking. Regattas are scored in order of their start date.
+type Series struct {
+	ID         string                  `json:"id"`
+	Name       string                  `json:"name"`
+	Discards   scoring.DiscardSchedule `json:"discards"`
+	MatchBy    string                  `json:"matchBy"`
+	RegattaIDs []string                `json:"regattaIds"`
+	// The club that owns the series; its regattas must belong to it too
+	OrganisationID string `json:"organisationId,omitempty"`
+}
+
+// validate checks the settings of a series.
+func (series *Series) validate() error {
+	if strings.TrimSpace(series.Name) == "" {
+		return Validation("name is required")
+	}
+	if series.MatchBy != MatchBySailNumber && series.MatchBy != MatchByHelm {
+		return Validation("matchBy must be %q or %q", MatchBySailNumber, MatchByHelm)
+	}
+	if err := series.Discards.Validate(); err != nil {
+		return Validation("%v", err)
+	}
+	return nil
+}
+
+// ListSeries returns the series of an organisation ordered by name, or of
+// every organisation when organisationId is empty.
+func (r *Repository) ListSeries(organisationId string) ([]Series, error) {
+	rows, err := r.db.Query("SELECT id FROM series WHERE ($1 = '' OR organisation_id = $1) ORDER BY name", organisationId)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	list := make([]Series, 0, len(ids))
+	for _, id := range ids {
+		series, err := r.GetSeries(id)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, series)
+	}
+	return list, nil
+}
+
+// GetSeries reads a series with its regattas in order of start date.
+func (r *Repository) GetSeries(seriesId string) (Series, error) {
+	var series Series
+	var discards string
+	err := r.db.QueryRow("SELECT id, name, discards, match_by, organisation_id FROM series WHERE id = $1", seriesId).
+		Scan(&series.ID, &series.Name, &discards, &series.MatchBy, &series.OrganisationID)
+	if err == sql.ErrNoRows {
+		return series, NotFound("Series not found")
+	}
+	if err != nil {
+		return series, err
+	}
+	series.Discards = ParseDiscards(discards)
+
+	rows, err := r.db.Query(`
+		SELECT r.id
+		FROM series_regattas s
+		JOIN regattas r ON s.regatta_id = r.id
+		WHERE s.series_id = $1
+		ORDER BY r.start_date, r.name`, seriesId)
+	if err != nil {
+		return series, err
+	}
+	defer rows.Close()
+
+	series.RegattaIDs = make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return series, err
+		}
+		series.RegattaIDs = append(series.RegattaIDs, id)
+	}
+	return series, rows.Err()
+}
+
+// CreateSeries validates and stores a new series without regattas, giving
+// it an ID. A series without a matching rule matches boats by sail number.
+func (r *Repository) CreateSeries(series *Series) error {
+	if series.MatchBy == "" {
+		series.MatchBy = MatchBySailNumber
+	}
+	if err := series.validate(); err != nil {
+		return err
+	}
+
+	series.ID = uuid.New().String()
+	series.RegattaIDs = make([]string, 0)
+
+	_, err := r.db.Exec("INSERT INTO series(id, name, discards, match_by, organisation_id) VALUES($1, $2, $3, $4, $5)",
+		series.ID, series.Name, series.Discards.String(), series.MatchBy, series.OrganisationID)
+	return err
+}
+
+// UpdateSeries validates and stores the name, discards and matching rule of
+// an existing series.
+func (r *Repository) UpdateSeries(series *Series) error {
+	if err := series.validate(); err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec("UPDATE series SET name = $1, discards = $2, match_by = $3 WHERE id = $4",
+		series.Name, series.Discards.String(), series.MatchBy, series.ID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, "Series not found")
+}
+
+// DeleteSeries removes a series. Its regattas are left untouched.
+func (r *Repository) DeleteSeries(seriesId string) error {
+	_, err := r.db.Exec("DELETE FROM series_regattas WHERE series_id = $1", seriesId)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec("DELETE FROM series WHERE id = $1", seriesId)
+	return err
+}
+
+// AddSeriesRegatta adds a regatta of the series' organisation to a series.
+func (r *Repository) AddSeriesRegatta(seriesId, regattaId string) error {
+	series, err := r.GetSeries(seriesId)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	err = r.db.QueryRow("SELECT COUNT(*) FROM regattas WHERE id = $1 AND organisation_id = $2", regattaId, series.OrganisationID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return Validation("Regatta not found")
+	}
+
+	for _, id := range series.RegattaIDs {
+		if id == regattaId {
+			return Conflict("Regatta is already part of this series")
+		}
+	}
+
+	_, err = r.db.Exec("INSERT INTO series_regattas(series_id, regatta_id) VALUES($1, $2)", seriesId, regattaId)
+	return err
+}
+
+// RemoveSeriesRegatta removes a regatta from a series.
+func (r *Repository) RemoveSeriesRegatta(seriesId, regattaId string) error {
+	result, err := r.db.Exec("DELETE FROM series_regattas WHERE series_id = $1 AND regatta_id = $2", seriesId, regattaId)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, "Regatta is not part of this series")
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// EntryDetails describe the boat and people behind an entry, as published on
+// results and used to check eligibility. The sail number is unique within a
+// fleet.
+type EntryDetails struct {
+	SailNumber string   `json:"sailNumber,omitempty"`
+	BoatName   string   `json:"boatName,omitempty"`
+	Class      string   `json:"class,omitempty"`
+	Helm       string   `json:"helm,omitempty"`
+	Crew       []string `json:"crew,omitempty"`
+	Club       string   `json:"club,omitempty"`
+	// Country is the three-letter IOC code of the boat's nationality
+	Country string `json:"country,omitempty"`
+}
+
+type Team struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	RegattaID string  `json:"regattaId"`
+	FleetID   string  `json:"fleetId,omitempty"`
+	Rating    float64 `json:"rating,omitempty"`
+	EntryDetails
+}
+
+// teamColumns lists the teams columns read by scanTeam, in order.
+const teamColumns = "id, name, regatta_id, fleet_id, rating, sail_number, boat_name, class, helm, crew, club, country"
+
+// scanTeam reads a team selected with teamColumns.
+func scanTeam(row interface{ Scan(...any) error }) (Team, error) {
+	var team Team
+	var crew string
+	err := row.Scan(&team.ID, &team.Name, &team.RegattaID, &team.FleetID, &team.Rating,
+		&team.SailNumber, &team.BoatName, &team.Class, &team.Helm, &crew, &team.Club, &team.Country)
+	if err != nil {
+		return team, err
+	}
+	team.Crew = parseCrew(crew)
+	return team, nil
+}
+
+// Normalize trims the entry details and upper-cases the country code.
+func (e *EntryDetails) Normalize() {
+	e.SailNumber = strings.TrimSpace(e.SailNumber)
+	e.BoatName = strings.TrimSpace(e.BoatName)
+	e.Class = strings.TrimSpace(e.Class)
+	e.Helm = strings.TrimSpace(e.Helm)
+	e.Club = strings.TrimSpace(e.Club)
+	e.Country = strings.ToUpper(strings.TrimSpace(e.Country))
+
+	crew := make([]string, 0, len(e.Crew))
+	for _, name := range e.Crew {
+		if name = strings.TrimSpace(name); name != "" {
+			crew = append(crew, name)
+		}
+	}
+	e.Crew = crew
+}
+
+// Validate checks the entry details after Normalize.
+func (e EntryDetails) Validate() error {
+	if e.Country == "" {
+		return nil
+	}
+	if len(e.Country) != 3 {
+		return fmt.Errorf("country must be a three-letter code, got %q", e.Country)
+	}
+	for _, c := range e.Country {
+		if c < 'A' || c > 'Z' {
+			return fmt.Errorf("country must be a three-letter code, got %q", e.Country)
+		}
+	}
+	return nil
+}
+
+// formatCrew encodes crew names for the crew column.
+func formatCrew(crew []string) string {
+	if len(crew) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(crew)
+	return string(data)
+}
+
+// parseCrew decodes the crew column.
+func parseCrew(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var crew []string
+	if err := json.Unmarshal([]byte(s), &crew); err != nil {
+		log.Printf("Invalid crew %q: %v", s, err)
+	}
+	return crew
+}
+
+// SailNumberTaken reports whether another team in the same fleet of a
+// regatta already uses a sail number. Sail numbers compare without regard to
+// case.
+func (r *Repository) SailNumberTaken(regattaId, fleetId, sailNumber, teamId string) (bool, error) {
+	if sailNumber == "" {
+		return false, nil
+	}
+
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM teams WHERE regatta_id = $1 AND fleet_id = $2 AND UPPER(sail_number) = UPPER($3) AND id <> $4",
+		regattaId, fleetId, sailNumber, teamId).Scan(&count)
+	return count > 0, err
+}
+
+// FleetExists reports whether a fleet belongs to a regatta. The empty fleet
+// ID, meaning no fleet, always exists.
+func (r *Repository) FleetExists(regattaId, fleetId string) (bool, error) {
+	if fleetId == "" {
+		return true, nil
+	}
+
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM fleets WHERE id = $1 AND regatta_id = $2", fleetId, regattaId).Scan(&count)
+	return count > 0, err
+}
+
+// ListTeams returns the teams of a regatta ordered by name.
+func (r *Repository) ListTeams(regattaId string) ([]Team, error) {
+	rows, err := r.db.Query("SELECT "+teamColumns+" FROM teams WHERE regatta_id = $1 ORDER BY name", regattaId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := make([]Team, 0)
+	for rows.Next() {
+		team, err := scanTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+	return teams, rows.Err()
+}
+
+// GetTeam reads a team of a regatta.
+func (r *Repository) GetTeam(regattaId, teamId string) (Team, error) {
+	team, err := scanTeam(r.db.QueryRow("SELECT "+teamColumns+" FROM teams WHERE id = $1 AND regatta_id = $2", teamId, regattaId))
+	if err == sql.ErrNoRows {
+		return team, NotFound("Team not found or doesn't belong to this regatta")
+	}
+	return team, err
+}
+
+// check normalizes a team's entry details and checks it may be stored in
+// its regatta.
+func (r *Repository) check(team *Team) error {
+	if team.Rating < 0 {
+		return Validation("rating must not be negative")
+	}
+
+	team.Normalize()
+	if err := team.Validate(); err != nil {
+		return Validation("%v", err)
+	}
+
+	exists, err := r.FleetExists(team.RegattaID, team.FleetID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return Validation("Fleet not found or doesn't belong to this regatta")
+	}
+
+	taken, err := r.SailNumberTaken(team.RegattaID, team.FleetID, team.SailNumber, team.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return Conflict("Sail number is already used in this fleet")
+	}
+	return nil
+}
+
+// CreateTeam checks and stores a new team of its regatta, giving it an ID.
+func (r *Repository) CreateTeam(team *Team) error {
+	team.ID = uuid.New().String()
+	if err := r.check(team); err != nil {
+		return err
+	}
+	return r.InsertTeam(*team)
+}
+
+// InsertTeam stores a team as it is, for imports that have already checked
+// their teams against each other and the regatta. A sail number another
+// request took in the meantime is still reported as a conflict.
+func (r *Repository) InsertTeam(team Team) error {
+	_, err := r.db.Exec("INSERT INTO teams(id, name, regatta_id, fleet_id, rating, sail_number, boat_name, class, helm, crew, club, country) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)",
+		team.ID, team.Name, team.RegattaID, team.FleetID, team.Rating,
+		team.SailNumber, team.BoatName, team.Class, team.Helm, formatCrew(team.Crew), team.Club, team.Country)
+	if isUniqueViolation(err) {
+		return Conflict("Sail number is already used in this fleet")
+	}
+	return err
+}
+
+// UpdateTeam checks and stores the details of an existing team.
+func (r *Repository) UpdateTeam(team *Team) error {
+	if err := r.check(team); err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(`UPDATE teams SET name = $1, fleet_id = $2, rating = $3, sail_number = $4, boat_name = $5, class = $6,
+		helm = $7, crew = $8, club = $9, country = $10 WHERE id = $11 AND regatta_id = $12`,
+		team.Name, team.FleetID, team.Rating, team.SailNumber, team.BoatName, team.Class,
+		team.Helm, formatCrew(team.Crew), team.Club, team.Country, team.ID, team.RegattaID)
+	if isUniqueViolation(err) {
+		return Conflict("Sail number is already used in this fleet")
+	}
+	if err != nil {
+		return err
+	}
+	return expectRow(result, "Team not found or doesn't belong to this regatta")
+}
+
+// DeleteTeam removes a team of a regatta. One with results is kept and
+// reported as a conflict.
+func (r *Repository) DeleteTeam(regattaId, teamId string) error {
+	result, err := r.db.Exec("DELETE FROM teams WHERE id = $1 AND regatta_id = $2", teamId, regattaId)
+	if isForeignKeyViolation(err) {
+		return Conflict("Team still has race results")
+	}
+	if err != nil {
+		return err
+	}
+	return expectRow(result, "Team not found or doesn't belong to this regatta")
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User is an account that signs in to change regattas. Its password is
+// stored only as a bcrypt hash and never returned. A user belongs to the
+// organisation whose data it may see; an admin of no organisation is a site
+// admin.
+type User struct {
+	ID             string    `json:"id"`
+	Username       string    `json:"username"`
+	Password       string    `json:"password,omitempty"`
+	Role           string    `json:"role"`
+	OrganisationID string    `json:"organisationId,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// userColumns lists the users columns read by scanUser, in order.
+const userColumns = "id, username, role, organisation_id, created_at"
+
+// scanUser reads a user selected with userColumns.
+func scanUser(row interface{ Scan(...any) error }) (User, error) {
+	var user User
+	err := row.Scan(&user.ID, &user.Username, &user.Role, &user.OrganisationID, &user.CreatedAt)
+	return user, err
+}
+
+// SessionUser returns the user of an unexpired session, found by the hash of
+// its token.
+func (r *Repository) SessionUser(tokenHash string, now time.Time) (User, error) {
+	user, err := scanUser(r.db.QueryRow(`SELECT u.id, u.username, u.role, u.organisation_id, u.created_at FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = $1 AND s.expires_at > $2`, tokenHash, now))
+	if err == sql.ErrNoRows {
+		return user, NotFound("Session is invalid or has expired")
+	}
+	return user, err
+}
+
+// UserCredentials returns a user by username with its password hash.
+func (r *Repository) UserCredentials(username string) (User, string, error) {
+	var user User
+	var hash string
+	err := r.db.QueryRow("SELECT "+userColumns+", password_hash FROM users WHERE username = $1", username).
+		Scan(&user.ID, &user.Username, &user.Role, &user.OrganisationID, &user.CreatedAt, &hash)
+	if err == sql.ErrNoRows {
+		return user, "", NotFound("User not found")
+	}
+	return user, hash, err
+}
+
+// CreateSession stores a session of a user by the hash of its token.
+func (r *Repository) CreateSession(tokenHash, userId string, createdAt, expiresAt time.Time) error {
+	_, err := r.db.Exec("INSERT INTO sessions (token_hash, user_id, created_at, expires_at) VALUES ($1, $2, $3, $4)",
+		tokenHash, userId, createdAt, expiresAt)
+	return err
+}
+
+// DeleteSession ends a session, found by the hash of its token.
+func (r *Repository) DeleteSession(tokenHash string) error {
+	_, err := r.db.Exec("DELETE FROM sessions WHERE token_hash = $1", tokenHash)
+	return err
+}
+
+// DeleteExpiredSessions removes the sessions that expired by a time.
+func (r *Repository) DeleteExpiredSessions(now time.Time) error {
+	_, err := r.db.Exec("DELETE FROM sessions WHERE expires_at <= $1", now)
+	return err
+}
+
+// CountUsers returns the number of users.
+func (r *Repository) CountUsers() (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+	return count, err
+}
+
+// ListUsers returns the users of an organisation ordered by username, or
+// every user when organisationId is empty.
+func (r *Repository) ListUsers(organisationId string) ([]User, error) {
+	rows, err := r.db.Query("SELECT "+userColumns+" FROM users WHERE ($1 = '' OR organisation_id = $1) ORDER BY username", organisationId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// GetUser reads a user of an organisation, or any user when organisationId
+// is empty.
+func (r *Repository) GetUser(userId, organisationId string) (User, error) {
+	user, err := scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = $1 AND ($2 = '' OR organisation_id = $2)",
+		userId, organisationId))
+	if err == sql.ErrNoRows {
+		return user, NotFound("User not found")
+	}
+	return user, err
+}
+
+// CreateUser stores a new user with the hash of its password, giving it an
+// ID and creation time and clearing the password. Usernames are unique.
+func (r *Repository) CreateUser(user *User, passwordHash string) error {
+	user.Username = strings.TrimSpace(user.Username)
+	if user.Username == "" {
+		return Validation("username is required")
+	}
+
+	var count int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM users WHERE username = $1", user.Username).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return Conflict("Username is already taken")
+	}
+
+	user.ID = uuid.New().String()
+	user.CreatedAt = time.Now().UTC()
+	user.Password = ""
+	_, err := r.db.Exec("INSERT INTO users (id, username, password_hash, role, organisation_id, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		user.ID, user.Username, passwordHash, user.Role, user.OrganisationID, user.CreatedAt)
+	if isUniqueViolation(err) {
+		return Conflict("Username is already taken")
+	}
+	return err
+}
+
+// SetUserPassword stores the hash of a user's new password and ends the
+// user's sessions.
+func (r *Repository) SetUserPassword(userId, passwordHash string) error {
+	if _, err := r.db.Exec("UPDATE users SET password_hash = $1 WHERE id = $2", passwordHash, userId); err != nil {
+		return err
+	}
+	_, err := r.db.Exec("DELETE FROM sessions WHERE user_id = $1", userId)
+	return err
+}
+
+// SetUserRole stores the role of a user.
+func (r *Repository) SetUserRole(userId, role string) error {
+	result, err := r.db.Exec("UPDATE users SET role = $1 WHERE id = $2", role, userId)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, "User not found")
+}
+
+// DeleteUser removes a user and its sessions.
+func (r *Repository) DeleteUser(userId string) error {
+	if _, err := r.db.Exec("DELETE FROM sessions WHERE user_id = $1", userId); err != nil {
+		return err
+	}
+	result, err := r.db.Exec("DELETE FROM users WHERE id = $1", userId)
+	if err != nil {
+		return err
+	}
+	return expectRow(result, "User not found")
+}
@@ -0,0 +1,419 @@
+package sailwave
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Sailwave .blw file is a list of quoted rows of four columns: a field
+// name, its value, the competitor ID and the race ID. Series fields leave
+// both IDs empty, competitor fields set the competitor ID, race fields set
+// the race ID and result fields set both. Fields this package does not know
+// are ignored on reading.
+const (
+	fieldEvent = "serevent"
+	fieldVenue = "servenue"
+
+	fieldSailNumber = "compsailno"
+	fieldBoat       = "compboat"
+	fieldClass      = "compclass"
+	fieldHelm       = "comphelmname"
+	fieldCrew       = "compcrewname"
+	fieldClub       = "compclub"
+	fieldNation     = "compnat"
+	fieldFleet      = "compfleet"
+	fieldRating     = "comprating"
+
+	fieldRaceName = "racename"
+	fieldRaceDate = "racedate"
+
+	fieldPosition         = "rpos"
+	fieldCode             = "rcod"
+	fieldPenalty          = "rpen"
+	fieldRedress          = "rrdg"
+	fieldRedressPoints    = "rrdgpts"
+	fieldScoringPenalties = "rscp"
+	fieldElapsed          = "rele"
+)
+
+// Series is the content of a .blw file: the event with its competitors,
+// races and results.
+type Series struct {
+	Event       string
+	Venue       string
+	Competitors []Competitor
+	Races       []Race
+	Results     []Result
+}
+
+type Competitor struct {
+	ID         string
+	SailNumber string
+	Boat       string
+	Class      string
+	Helm       string
+	Crew       string
+	Club       string
+	Nation     string
+	Fleet      string
+	Rating     float64
+}
+
+// Race is a race of the series. Races are sailed in order of their ID.
+type Race struct {
+	ID   string
+	Name string
+	Date string
+}
+
+// Result is a competitor's place or scoring code in a race. Penalty is the
+// points a DPI code adds to the place. An RDG code gives redress by the
+// method in RedressMode, with RedressPoints for fixed points.
+type Result struct {
+	CompetitorID  string
+	RaceID        string
+	Position      int
+	Code          string
+	Penalty       float64
+	RedressMode   string
+	RedressPoints float64
+	Penalties     []ScoringPenalty
+	Elapsed       time.Duration
+}
+
+// ScoringPenalty is an SCP or ZFP on a result, a percentage of the DNF
+// score. A file lists them in one field as "ZFP 20,SCP 30"; a penalty
+// without a percentage reads as 0.
+type ScoringPenalty struct {
+	Code    string
+	Percent float64
+}
+
+// Read parses a .blw file.
+func Read(r io.Reader) (*Series, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	series := &Series{}
+	competitors := make(map[string]*Competitor)
+	races := make(map[string]*Race)
+	results := make(map[[2]string]*Result)
+
+	for line := 1; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for len(row) < 4 {
+			row = append(row, "")
+		}
+		field, value, compId, raceId := row[0], strings.TrimSpace(row[1]), row[2], row[3]
+
+		switch {
+		case compId != "" && raceId != "":
+			key := [2]string{compId, raceId}
+			result, exists := results[key]
+			if !exists {
+				result = &Result{CompetitorID: compId, RaceID: raceId}
+				results[key] = result
+			}
+			if err := result.set(field, value); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+
+		case compId != "":
+			competitor, exists := competitors[compId]
+			if !exists {
+				competitor = &Competitor{ID: compId}
+				competitors[compId] = competitor
+			}
+			if err := competitor.set(field, value); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+
+		case raceId != "":
+			race, exists := races[raceId]
+			if !exists {
+				race = &Race{ID: raceId}
+				races[raceId] = race
+			}
+			race.set(field, value)
+
+		default:
+			switch field {
+			case fieldEvent:
+				series.Event = value
+			case fieldVenue:
+				series.Venue = value
+			}
+		}
+	}
+
+	for _, competitor := range competitors {
+		series.Competitors = append(series.Competitors, *competitor)
+	}
+	sort.Slice(series.Competitors, func(i, j int) bool {
+		return lessID(series.Competitors[i].ID, series.Competitors[j].ID)
+	})
+
+	for _, race := range races {
+		series.Races = append(series.Races, *race)
+	}
+	sort.Slice(series.Races, func(i, j int) bool {
+		return lessID(series.Races[i].ID, series.Races[j].ID)
+	})
+
+	for _, result := range results {
+		if result.Position == 0 && result.Code == "" {
+			continue
+		}
+		series.Results = append(series.Results, *result)
+	}
+	sort.Slice(series.Results, func(i, j int) bool {
+		a, b := series.Results[i], series.Results[j]
+		if a.RaceID != b.RaceID {
+			return lessID(a.RaceID, b.RaceID)
+		}
+		return lessID(a.CompetitorID, b.CompetitorID)
+	})
+
+	return series, nil
+}
+
+// Write writes a series as a .blw file.
+func Write(w io.Writer, series *Series) error {
+	rows := [][]string{
+		{fieldEvent, series.Event, "", ""},
+		{fieldVenue, series.Venue, "", ""},
+	}
+
+	for _, c := range series.Competitors {
+		rows = append(rows,
+			[]string{fieldSailNumber, c.SailNumber, c.ID, ""},
+			[]string{fieldBoat, c.Boat, c.ID, ""},
+			[]string{fieldClass, c.Class, c.ID, ""},
+			[]string{fieldHelm, c.Helm, c.ID, ""},
+			[]string{fieldCrew, c.Crew, c.ID, ""},
+			[]string{fieldClub, c.Club, c.ID, ""},
+			[]string{fieldNation, c.Nation, c.ID, ""},
+			[]string{fieldFleet, c.Fleet, c.ID, ""},
+		)
+		if c.Rating != 0 {
+			rows = append(rows, []string{fieldRating, strconv.FormatFloat(c.Rating, 'f', -1, 64), c.ID, ""})
+		}
+	}
+
+	for _, race := range series.Races {
+		rows = append(rows,
+			[]string{fieldRaceName, race.Name, "", race.ID},
+			[]string{fieldRaceDate, race.Date, "", race.ID},
+		)
+	}
+
+	for _, result := range series.Results {
+		if result.Position > 0 {
+			rows = append(rows, []string{fieldPosition, strconv.Itoa(result.Position), result.CompetitorID, result.RaceID})
+		}
+		if result.Code != "" {
+			rows = append(rows, []string{fieldCode, result.Code, result.CompetitorID, result.RaceID})
+		}
+		if result.Penalty > 0 {
+			rows = append(rows, []string{fieldPenalty, strconv.FormatFloat(result.Penalty, 'f', -1, 64), result.CompetitorID, result.RaceID})
+		}
+		if result.RedressMode != "" {
+			rows = append(rows, []string{fieldRedress, result.RedressMode, result.CompetitorID, result.RaceID})
+		}
+		if result.RedressPoints > 0 {
+			rows = append(rows, []string{fieldRedressPoints, strconv.FormatFloat(result.RedressPoints, 'f', -1, 64), result.CompetitorID, result.RaceID})
+		}
+		if len(result.Penalties) > 0 {
+			rows = append(rows, []string{fieldScoringPenalties, formatPenalties(result.Penalties), result.CompetitorID, result.RaceID})
+		}
+		if result.Elapsed > 0 {
+			rows = append(rows, []string{fieldElapsed, formatElapsed(result.Elapsed), result.CompetitorID, result.RaceID})
+		}
+	}
+
+	// Sailwave quotes every value
+	for _, row := range rows {
+		quoted := make([]string, len(row))
+		for i, value := range row {
+			quoted[i] = `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+		}
+		if _, err := io.WriteString(w, strings.Join(quoted, ",")+"\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Competitor) set(field, value string) error {
+	switch field {
+	case fieldSailNumber:
+		c.SailNumber = value
+	case fieldBoat:
+		c.Boat = value
+	case fieldClass:
+		c.Class = value
+	case fieldHelm:
+		c.Helm = value
+	case fieldCrew:
+		c.Crew = value
+	case fieldClub:
+		c.Club = value
+	case fieldNation:
+		c.Nation = value
+	case fieldFleet:
+		c.Fleet = value
+	case fieldRating:
+		if value == "" {
+			return nil
+		}
+		rating, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid rating %q for competitor %s", value, c.ID)
+		}
+		c.Rating = rating
+	}
+	return nil
+}
+
+func (r *Race) set(field, value string) {
+	switch field {
+	case fieldRaceName:
+		r.Name = value
+	case fieldRaceDate:
+		r.Date = value
+	}
+}
+
+func (r *Result) set(field, value string) error {
+	switch field {
+	case fieldPosition:
+		if value == "" {
+			return nil
+		}
+		position, err := strconv.Atoi(value)
+		if err != nil || position < 0 {
+			return fmt.Errorf("invalid position %q for competitor %s in race %s", value, r.CompetitorID, r.RaceID)
+		}
+		r.Position = position
+	case fieldCode:
+		r.Code = strings.ToUpper(value)
+	case fieldPenalty:
+		if value == "" {
+			return nil
+		}
+		penalty, err := strconv.ParseFloat(value, 64)
+		if err != nil || penalty < 0 {
+			return fmt.Errorf("invalid penalty %q for competitor %s in race %s", value, r.CompetitorID, r.RaceID)
+		}
+		r.Penalty = penalty
+	case fieldRedress:
+		r.RedressMode = strings.ToUpper(value)
+	case fieldRedressPoints:
+		if value == "" {
+			return nil
+		}
+		points, err := strconv.ParseFloat(value, 64)
+		if err != nil || points < 0 {
+			return fmt.Errorf("invalid redress points %q for competitor %s in race %s", value, r.CompetitorID, r.RaceID)
+		}
+		r.RedressPoints = points
+	case fieldScoringPenalties:
+		penalties, err := parsePenalties(value)
+		if err != nil {
+			return fmt.Errorf("invalid scoring penalties %q for competitor %s in race %s", value, r.CompetitorID, r.RaceID)
+		}
+		r.Penalties = penalties
+	case fieldElapsed:
+		if value == "" {
+			return nil
+		}
+		elapsed, err := parseElapsed(value)
+		if err != nil {
+			return fmt.Errorf("invalid elapsed time %q for competitor %s in race %s", value, r.CompetitorID, r.RaceID)
+		}
+		r.Elapsed = elapsed
+	}
+	return nil
+}
+
+// parsePenalties reads scoring penalties written as "ZFP 20,SCP 30".
+func parsePenalties(s string) ([]ScoringPenalty, error) {
+	var penalties []ScoringPenalty
+	for _, item := range strings.Split(s, ",") {
+		parts := strings.Fields(item)
+		if len(parts) == 0 {
+			continue
+		}
+		if len(parts) > 2 {
+			return nil, fmt.Errorf("invalid scoring penalty %q", item)
+		}
+		penalty := ScoringPenalty{Code: strings.ToUpper(parts[0])}
+		if len(parts) == 2 {
+			percent, err := strconv.ParseFloat(parts[1], 64)
+			if err != nil || percent < 0 {
+				return nil, fmt.Errorf("invalid scoring penalty %q", item)
+			}
+			penalty.Percent = percent
+		}
+		penalties = append(penalties, penalty)
+	}
+	return penalties, nil
+}
+
+// formatPenalties writes scoring penalties as "ZFP 20,SCP 30".
+func formatPenalties(penalties []ScoringPenalty) string {
+	items := make([]string, len(penalties))
+	for i, penalty := range penalties {
+		items[i] = penalty.Code
+		if penalty.Percent > 0 {
+			items[i] += " " + strconv.FormatFloat(penalty.Percent, 'f', -1, 64)
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+// parseElapsed reads an elapsed time written as h:mm:ss or mm:ss.
+func parseElapsed(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid elapsed time %q", s)
+	}
+
+	var total time.Duration
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid elapsed time %q", s)
+		}
+		total = total*60 + time.Duration(n)
+	}
+	return total * time.Second, nil
+}
+
+// formatElapsed writes an elapsed time as h:mm:ss.
+func formatElapsed(d time.Duration) string {
+	seconds := int(d.Round(time.Second) / time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// lessID orders Sailwave IDs numerically when both are numbers.
+func lessID(a, b string) bool {
+	x, errX := strconv.Atoi(a)
+	y, errY := strconv.Atoi(b)
+	if errX == nil && errY == nil {
+		return x < y
+	}
+	return a < b
+}
@@ -0,0 +1,108 @@
+package sailwave
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	series := &Series{
+		Event: "Spring Cup",
+		Venue: "Harbour",
+		Competitors: []Competitor{
+			{ID: "1", SailNumber: "GBR 1", Boat: "Swift", Class: "Laser", Helm: "Ann Lee", Crew: "Bo, Cy", Club: "HYC", Nation: "GBR", Fleet: "Gold", Rating: 1100},
+			{ID: "2", SailNumber: "2", Boat: `The "Quote"`, Fleet: "Gold"},
+			{ID: "10", SailNumber: "10", Boat: "Late", Fleet: "Silver", Rating: 1025.5},
+		},
+		Races: []Race{
+			{ID: "1", Name: "R1", Date: "2026-04-01"},
+			{ID: "2", Name: "R2", Date: "2026-04-02"},
+		},
+		Results: []Result{
+			{CompetitorID: "1", RaceID: "1", Position: 1, Elapsed: 45*time.Minute + 3*time.Second},
+			{CompetitorID: "2", RaceID: "1", Position: 2, Code: "DPI", Penalty: 2},
+			{CompetitorID: "10", RaceID: "1", Code: "DNF"},
+			{CompetitorID: "1", RaceID: "2", Position: 2, Code: "RDG", RedressMode: "FIXED", RedressPoints: 1.5},
+			{CompetitorID: "2", RaceID: "2", Position: 1, Elapsed: 2*time.Hour + 5*time.Second},
+			{CompetitorID: "10", RaceID: "2", Position: 3, Penalties: []ScoringPenalty{{Code: "ZFP", Percent: 20}, {Code: "SCP", Percent: 12.5}}},
+		},
+	}
+
+	var file bytes.Buffer
+	if err := Write(&file, series); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, series) {
+		t.Errorf("read back\n%+v\nwant\n%+v", got, series)
+	}
+}
+
+func TestRead(t *testing.T) {
+	file := strings.Join([]string{
+		`"serevent","Cup","",""`,
+		`"sertype","ignored","",""`,
+		`"compsailno","10","10",""`,
+		`"compsailno","2","2",""`,
+		`"racename","R1","","1"`,
+		`"rpos","1","10","1"`,
+		`"rele","45:30","10","1"`,
+		`"rcod","dnf","2","1"`,
+		`"rpos","","2","2"`,
+		`"rpos","2","10","2"`,
+		`"rcod","rdg","10","2"`,
+		`"rrdg","average","10","2"`,
+		`"rpos","1","2","3"`,
+		`"rscp","zfp, scp 10","2","3"`,
+	}, "\r\n")
+
+	got, err := Read(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Series{
+		Event:       "Cup",
+		Competitors: []Competitor{{ID: "2", SailNumber: "2"}, {ID: "10", SailNumber: "10"}},
+		Races:       []Race{{ID: "1", Name: "R1"}},
+		Results: []Result{
+			{CompetitorID: "2", RaceID: "1", Code: "DNF"},
+			{CompetitorID: "10", RaceID: "1", Position: 1, Elapsed: 45*time.Minute + 30*time.Second},
+			{CompetitorID: "10", RaceID: "2", Position: 2, Code: "RDG", RedressMode: "AVERAGE"},
+			{CompetitorID: "2", RaceID: "3", Position: 1, Penalties: []ScoringPenalty{{Code: "ZFP"}, {Code: "SCP", Percent: 10}}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		row  string
+	}{
+		{"invalid position", `"rpos","first","1","1"`},
+		{"negative position", `"rpos","-1","1","1"`},
+		{"invalid penalty", `"rpen","two","1","1"`},
+		{"negative penalty", `"rpen","-2","1","1"`},
+		{"invalid redress points", `"rrdgpts","some","1","1"`},
+		{"scoring penalty with two percentages", `"rscp","ZFP 20 30","1","1"`},
+		{"invalid scoring penalty", `"rscp","SCP ten","1","1"`},
+		{"invalid elapsed time", `"rele","1:xx:00","1","1"`},
+		{"invalid rating", `"comprating","fast","1",""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Read(strings.NewReader(tt.row)); err == nil {
+				t.Error("want an error")
+			}
+		})
+	}
+}
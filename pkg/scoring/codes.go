@@ -0,0 +1,71 @@
+package scoring
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Code is a scoring abbreviation recorded instead of, or on top of, a
+// finishing place (RRS A11).
+type Code string
+
+const (
+	DNC Code = "DNC" // Did not come to the starting area
+	DNS Code = "DNS" // Came to the starting area but did not start
+	OCS Code = "OCS" // On the course side at the start
+	BFD Code = "BFD" // Disqualified under rule 30.4 (black flag)
+	UFD Code = "UFD" // Disqualified under rule 30.3 (U flag)
+	DNF Code = "DNF" // Started but did not finish
+	RET Code = "RET" // Retired
+	DSQ Code = "DSQ" // Disqualified
+	DNE Code = "DNE" // Disqualification that is not excludable
+	DPI Code = "DPI" // Discretionary penalty imposed by the protest committee
+	RDG Code = "RDG" // Redress given
+)
+
+var codes = map[Code]bool{
+	DNC: true, DNS: true, OCS: true, BFD: true, UFD: true,
+	DNF: true, RET: true, DSQ: true, DNE: true, DPI: true, RDG: true,
+}
+
+// ParseCode normalises and validates a scoring code. An empty string means
+// the boat finished and is scored by place.
+func ParseCode(s string) (Code, error) {
+	code := Code(strings.ToUpper(strings.TrimSpace(s)))
+	if code == "" || codes[code] {
+		return code, nil
+	}
+	return "", fmt.Errorf("unknown scoring code %q", s)
+}
+
+// Discardable reports whether a result with this code may be excluded.
+func (c Code) Discardable() bool {
+	return c != DNE
+}
+
+// ValidatePenalty checks the penalty percentage given with a code. Only a
+// DPI carries a penalty, which must be more than 0 and at most 100 percent.
+func ValidatePenalty(code Code, penalty float64) error {
+	if code != DPI {
+		if penalty != 0 {
+			return fmt.Errorf("a penalty can only be given with %s", DPI)
+		}
+		return nil
+	}
+	if penalty <= 0 || penalty > 100 {
+		return fmt.Errorf("%s penalty must be more than 0 and at most 100 percent", DPI)
+	}
+	return nil
+}
+
+// KeepsPlace reports whether a boat with this code keeps its finishing
+// place, so that the boats behind it do not move up (RRS A6).
+func (c Code) KeepsPlace() bool {
+	return c == "" || c == DPI || c == RDG
+}
+
+// Points returns the score for a coded result: one more than the number of
+// boats entered in the series (RRS A5.2).
+func (c Code) Points(entries int) float64 {
+	return float64(entries + 1)
+}
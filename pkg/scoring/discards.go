@@ -0,0 +1,100 @@
+package scoring
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiscardSchedule lists how many races must be sailed before each discard
+// applies. A schedule of [4 8] drops the worst race once four races are
+// sailed and the two worst races once eight are sailed (RRS A2.1).
+type DiscardSchedule []int
+
+// ParseDiscardSchedule parses a comma-separated schedule such as "4,8".
+func ParseDiscardSchedule(s string) (DiscardSchedule, error) {
+	var schedule DiscardSchedule
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		races, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid discard threshold %q", part)
+		}
+		schedule = append(schedule, races)
+	}
+
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Validate checks that thresholds are positive and strictly increasing.
+func (d DiscardSchedule) Validate() error {
+	for i, races := range d {
+		if races < 1 {
+			return fmt.Errorf("discard threshold must be 1 or greater, got %d", races)
+		}
+		if i > 0 && races <= d[i-1] {
+			return fmt.Errorf("discard thresholds must be increasing, got %d after %d", races, d[i-1])
+		}
+	}
+	return nil
+}
+
+// String formats the schedule in the form accepted by ParseDiscardSchedule.
+func (d DiscardSchedule) String() string {
+	parts := make([]string, len(d))
+	for i, races := range d {
+		parts[i] = strconv.Itoa(races)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Discards returns the number of results to exclude once races have been sailed.
+func (d DiscardSchedule) Discards(races int) int {
+	n := 0
+	for _, threshold := range d {
+		if races >= threshold {
+			n++
+		}
+	}
+	return n
+}
+
+// applyDiscards marks the worst n discardable results of a standing as
+// discarded and recomputes its net points. Among equal scores the later race
+// is dropped.
+func applyDiscards(s *Standing, n int) {
+	var order []int
+	for i, r := range s.Results {
+		if r.Code.Discardable() {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ra, rb := s.Results[order[a]], s.Results[order[b]]
+		if ra.Points != rb.Points {
+			return ra.Points > rb.Points
+		}
+		return ra.RaceNumber > rb.RaceNumber
+	})
+
+	if n > len(order) {
+		n = len(order)
+	}
+	for _, i := range order[:n] {
+		s.Results[i].Discarded = true
+	}
+
+	s.NetPoints = 0
+	for _, r := range s.Results {
+		if !r.Discarded {
+			s.NetPoints += r.Points
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Scoring penalty codes, recorded on top of a finishing place rather than
+// instead of it.
+const (
+	SCP Code = "SCP" // Scoring penalty under rule 44.3(a)
+	ZFP Code = "ZFP" // 20% penalty under rule 30.2 (Z flag)
+)
+
+// zfpPercent is the penalty of a Z flag infringement (RRS 30.2).
+const zfpPercent = 20
+
+// ScoringPenalty is a percentage penalty added to a boat's finishing place.
+// A boat may collect several, e.g. a ZFP at each of two starts.
+type ScoringPenalty struct {
+	Code    Code    `json:"code"`
+	Percent float64 `json:"percent"`
+}
+
+// ParseScoringPenalties normalises and validates the scoring penalties of a
+// result with the given code. A ZFP without a percentage is 20%. Only a boat
+// scored by its finishing place, with no code or a DPI, can take one.
+func ParseScoringPenalties(code Code, penalties []ScoringPenalty) ([]ScoringPenalty, error) {
+	if len(penalties) == 0 {
+		return nil, nil
+	}
+	if code != "" && code != DPI {
+		return nil, fmt.Errorf("scoring penalties need a finishing place")
+	}
+
+	parsed := make([]ScoringPenalty, len(penalties))
+	for i, p := range penalties {
+		p.Code = Code(strings.ToUpper(strings.TrimSpace(string(p.Code))))
+		if p.Code != SCP && p.Code != ZFP {
+			return nil, fmt.Errorf("unknown scoring penalty %q", p.Code)
+		}
+		if p.Code == ZFP && p.Percent == 0 {
+			p.Percent = zfpPercent
+		}
+		if p.Percent <= 0 || p.Percent > 100 {
+			return nil, fmt.Errorf("%s penalty must be more than 0 and at most 100 percent", p.Code)
+		}
+		parsed[i] = p
+	}
+	return parsed, nil
+}
+
+// penaltyPoints returns the points a placed result adds for its DPI and its
+// scoring penalties. Each penalty is converted to points on its own and the
+// points are stacked.
+func penaltyPoints(r Result, entries int) float64 {
+	var points float64
+	if r.Code == DPI {
+		points += PenaltyPoints(r.Penalty, entries)
+	}
+	for _, p := range r.Penalties {
+		points += PenaltyPoints(p.Percent, entries)
+	}
+	return points
+}
+
+// PenaltyPoints converts a percentage penalty into points: the percentage of
+// the DNF score, rounded to the nearest whole number with 0.5 rounded up
+// (RRS 44.3(c)).
+func PenaltyPoints(percent float64, entries int) float64 {
+	return math.Floor(percent/100*DNF.Points(entries) + 0.5 + epsilon)
+}
@@ -0,0 +1,120 @@
+package scoring
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPenaltyPoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		percent float64
+		entries int
+		want    float64
+	}{
+		{name: "whole points", percent: 20, entries: 9, want: 2},
+		{name: "rounded up", percent: 20, entries: 12, want: 3},
+		{name: "rounded down", percent: 20, entries: 6, want: 1},
+		{name: "half a point rounded up", percent: 30, entries: 4, want: 2},
+		{name: "half a point rounded up despite float error", percent: 10, entries: 14, want: 2},
+		{name: "full DNF score", percent: 100, entries: 9, want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PenaltyPoints(tt.percent, tt.entries); got != tt.want {
+				t.Errorf("%v%% of %d entries: got %v, want %v", tt.percent, tt.entries, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreRacePenalties(t *testing.T) {
+	tests := []struct {
+		name   string
+		result Result
+		want   float64
+	}{
+		{
+			name:   "ZFP adds 20 percent of DNF",
+			result: Result{TeamID: "A", Position: 3, Penalties: []ScoringPenalty{{Code: ZFP, Percent: 20}}},
+			want:   5,
+		},
+		{
+			name:   "SCP and ZFP stack",
+			result: Result{TeamID: "A", Position: 3, Penalties: []ScoringPenalty{{Code: SCP, Percent: 20}, {Code: ZFP, Percent: 20}}},
+			want:   7,
+		},
+		{
+			name:   "two ZFPs stack",
+			result: Result{TeamID: "A", Position: 1, Penalties: []ScoringPenalty{{Code: ZFP, Percent: 20}, {Code: ZFP, Percent: 20}}},
+			want:   5,
+		},
+		{
+			name:   "DPI adds its penalty",
+			result: Result{TeamID: "A", Position: 2, Code: DPI, Penalty: 30},
+			want:   5,
+		},
+		{
+			name:   "DPI and scoring penalty stack",
+			result: Result{TeamID: "A", Position: 2, Code: DPI, Penalty: 30, Penalties: []ScoringPenalty{{Code: SCP, Percent: 20}}},
+			want:   7,
+		},
+		{
+			name:   "capped at DNF",
+			result: Result{TeamID: "A", Position: 8, Penalties: []ScoringPenalty{{Code: SCP, Percent: 50}}},
+			want:   10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The penalised boat keeps its place, so the boat behind does not move up
+			results := []Result{tt.result, {TeamID: "B", Position: tt.result.Position + 1}}
+			got := racePoints(ScoreRace(results, 9))
+			if got["A"] != tt.want {
+				t.Errorf("penalised boat scored %v, want %v", got["A"], tt.want)
+			}
+			if want := float64(tt.result.Position + 1); got["B"] != want {
+				t.Errorf("boat behind scored %v, want %v", got["B"], want)
+			}
+		})
+	}
+}
+
+func TestParseScoringPenalties(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      Code
+		penalties []ScoringPenalty
+		want      []ScoringPenalty
+		wantErr   bool
+	}{
+		{name: "none", code: DSQ},
+		{name: "ZFP defaults to 20 percent", penalties: []ScoringPenalty{{Code: "zfp"}}, want: []ScoringPenalty{{Code: ZFP, Percent: 20}}},
+		{name: "SCP keeps its percentage", penalties: []ScoringPenalty{{Code: " scp ", Percent: 30}}, want: []ScoringPenalty{{Code: SCP, Percent: 30}}},
+		{name: "with a DPI", code: DPI, penalties: []ScoringPenalty{{Code: ZFP}}, want: []ScoringPenalty{{Code: ZFP, Percent: 20}}},
+		{name: "SCP needs a percentage", penalties: []ScoringPenalty{{Code: SCP}}, wantErr: true},
+		{name: "at most 100 percent", penalties: []ScoringPenalty{{Code: SCP, Percent: 101}}, wantErr: true},
+		{name: "unknown penalty", penalties: []ScoringPenalty{{Code: DSQ, Percent: 20}}, wantErr: true},
+		{name: "coded boats take no penalty", code: DNF, penalties: []ScoringPenalty{{Code: ZFP}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScoringPenalties(tt.code, tt.penalties)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
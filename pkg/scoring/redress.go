@@ -0,0 +1,102 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// RedressMode selects how the points of an RDG result are calculated
+// (RRS A10).
+type RedressMode string
+
+const (
+	// Average of the boat's points in all other races of the series
+	RedressAverage RedressMode = "AVERAGE"
+	// Average of the boat's points in the races before the race in question
+	RedressAverageBefore RedressMode = "AVERAGE_BEFORE"
+	// Points fixed by the protest committee
+	RedressFixed RedressMode = "FIXED"
+)
+
+// ParseRedress normalises and validates the redress mode and fixed points
+// given with a code. Only an RDG carries a redress mode, AVERAGE when none is
+// given; FIXED needs points greater than zero and the other modes none.
+func ParseRedress(code Code, mode string, points float64) (RedressMode, error) {
+	redress := RedressMode(strings.ToUpper(strings.TrimSpace(mode)))
+	if code != RDG {
+		if redress != "" || points != 0 {
+			return "", fmt.Errorf("redress can only be given with %s", RDG)
+		}
+		return "", nil
+	}
+
+	switch redress {
+	case "":
+		redress = RedressAverage
+	case RedressAverage, RedressAverageBefore, RedressFixed:
+	default:
+		return "", fmt.Errorf("unknown redress mode %q", mode)
+	}
+	if redress == RedressFixed && points <= 0 {
+		return "", fmt.Errorf("%s redress needs points greater than 0", RedressFixed)
+	}
+	if redress != RedressFixed && points != 0 {
+		return "", fmt.Errorf("redress points can only be given with %s redress", RedressFixed)
+	}
+	return redress, nil
+}
+
+// applyRedress gives each RDG result of a boat the points of its redress
+// mode and recomputes gross points. Averages are taken over the boat's races
+// without redress, rounded to the nearest tenth of a point with 0.05 rounded
+// up (RRS A10(a) and (b)). A boat with no race to average keeps the points of
+// its place. Since standings are always scored from the stored results,
+// averages follow every later change to the boat's other races.
+func applyRedress(s *Standing) {
+	s.GrossPoints = 0
+	for i, r := range s.Results {
+		if r.Code == RDG {
+			switch r.Redress {
+			case RedressFixed:
+				s.Results[i].Points = r.RedressPoints
+			case RedressAverageBefore:
+				if points, ok := averagePoints(s.Results, r.RaceNumber); ok {
+					s.Results[i].Points = points
+				}
+			default:
+				if points, ok := averagePoints(s.Results, 0); ok {
+					s.Results[i].Points = points
+				}
+			}
+		}
+		s.GrossPoints += s.Results[i].Points
+	}
+}
+
+// averagePoints averages the points of the results without redress, only
+// those of races numbered below before when it is not zero. It reports false
+// when there is no such result.
+func averagePoints(results []Result, before int) (float64, bool) {
+	var sum float64
+	var count int
+	for _, r := range results {
+		if r.Code == RDG || (before > 0 && r.RaceNumber >= before) {
+			continue
+		}
+		sum += r.Points
+		count++
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return roundTenth(sum / float64(count)), true
+}
+
+// roundTenth rounds points to the nearest tenth, 0.05 rounded up.
+func roundTenth(points float64) float64 {
+	return math.Floor(points*10+0.5+epsilon) / 10
+}
+
+// epsilon absorbs floating point error so that halves round up.
+const epsilon = 1e-9
@@ -0,0 +1,137 @@
+package scoring
+
+import "testing"
+
+func TestParseRedress(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    Code
+		mode    string
+		points  float64
+		want    RedressMode
+		wantErr bool
+	}{
+		{name: "no redress without RDG", code: DSQ},
+		{name: "mode without RDG", code: DSQ, mode: "FIXED", wantErr: true},
+		{name: "points without RDG", code: "", points: 3, wantErr: true},
+		{name: "average by default", code: RDG, want: RedressAverage},
+		{name: "mode is normalised", code: RDG, mode: " average_before ", want: RedressAverageBefore},
+		{name: "fixed points", code: RDG, mode: "FIXED", points: 3.5, want: RedressFixed},
+		{name: "fixed needs points", code: RDG, mode: "FIXED", wantErr: true},
+		{name: "average takes no points", code: RDG, mode: "AVERAGE", points: 3, wantErr: true},
+		{name: "unknown mode", code: RDG, mode: "BEST", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRedress(tt.code, tt.mode, tt.points)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreRedress(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []Result
+		race    int
+		want    float64
+		gross   float64
+	}{
+		{
+			name: "average of all other races",
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Position: 2},
+				{TeamID: "A", RaceNumber: 2, Position: 5, Code: RDG, Redress: RedressAverage},
+				{TeamID: "A", RaceNumber: 3, Position: 3},
+			},
+			race:  2,
+			want:  2.5,
+			gross: 7.5,
+		},
+		{
+			name: "average is rounded to a tenth",
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Position: 1},
+				{TeamID: "A", RaceNumber: 2, Position: 2},
+				{TeamID: "A", RaceNumber: 3, Position: 2},
+				{TeamID: "A", RaceNumber: 4, Position: 4, Code: RDG, Redress: RedressAverage},
+			},
+			race:  4,
+			want:  1.7,
+			gross: 6.7,
+		},
+		{
+			name: "average of 0.05 is rounded up",
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Position: 2}, {TeamID: "B", RaceNumber: 1, Position: 2},
+				{TeamID: "A", RaceNumber: 2, Position: 2},
+				{TeamID: "A", RaceNumber: 3, Position: 4, Code: RDG, Redress: RedressAverage},
+			},
+			race:  3,
+			want:  2.3,
+			gross: 6.8,
+		},
+		{
+			name: "average of the races before",
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Position: 1},
+				{TeamID: "A", RaceNumber: 2, Position: 4, Code: RDG, Redress: RedressAverageBefore},
+				{TeamID: "A", RaceNumber: 3, Position: 5},
+			},
+			race:  2,
+			want:  1,
+			gross: 7,
+		},
+		{
+			name: "fixed points",
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Position: 1},
+				{TeamID: "A", RaceNumber: 2, Position: 4, Code: RDG, Redress: RedressFixed, RedressPoints: 3.5},
+			},
+			race:  2,
+			want:  3.5,
+			gross: 4.5,
+		},
+		{
+			name: "no race to average keeps the place",
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Position: 4, Code: RDG, Redress: RedressAverage},
+			},
+			race:  1,
+			want:  4,
+			gross: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, standing := range Score(tt.results, Config{Entries: 10}) {
+				if standing.TeamID != "A" {
+					continue
+				}
+				if standing.GrossPoints != tt.gross {
+					t.Errorf("gross points %v, want %v", standing.GrossPoints, tt.gross)
+				}
+				for _, r := range standing.Results {
+					if r.RaceNumber == tt.race && r.Points != tt.want {
+						t.Errorf("redress scored %v, want %v", r.Points, tt.want)
+					}
+				}
+				return
+			}
+			t.Fatal("A has no standing")
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package scoring
+
+import (
+	"math"
+	"sort"
+)
+
+// Result is a single boat's finish in one race. Position is zero when the
+// boat has a Code and never finished. Penalty is the percentage of the DNF
+// score added to a DPI boat's place points. Penalties are the scoring
+// penalties stacked on top of the place. Redress and RedressPoints select
+// the points of an RDG.
+type Result struct {
+	TeamID        string
+	RaceNumber    int
+	Position      int
+	Code          Code
+	Penalty       float64
+	Penalties     []ScoringPenalty
+	Redress       RedressMode
+	RedressPoints float64
+	Points        float64
+	Discarded     bool
+}
+
+// Config holds the series settings used to score a regatta.
+type Config struct {
+	// Entries is the number of boats entered in the series. It is raised to
+	// the number of boats with results when lower.
+	Entries  int
+	Discards DiscardSchedule
+}
+
+// Standing is a boat's series score built from its race results. GrossPoints
+// counts every race, NetPoints leaves out the discarded ones. Rank is shared
+// by boats that remain tied after RRS A8.
+type Standing struct {
+	TeamID      string
+	Rank        int
+	GrossPoints float64
+	NetPoints   float64
+	Results     []Result
+}
+
+// PointsForPlace returns the low-point score for a finishing place (RRS A4.1).
+func PointsForPlace(place int) float64 {
+	return float64(place)
+}
+
+// ScoreRace assigns points to the results of a single race. Boats with a
+// code score entries plus one (RRS A5.2), and finishers behind a coded boat
+// that had a finishing place move up one place (RRS A6.1). Boats sharing a
+// finishing place are tied and share the average of the places involved (RRS A7).
+// A DPI boat keeps its place and adds its penalty, as do boats with scoring
+// penalties, never scoring worse than DNF (RRS 44.3(c)); an RDG boat keeps
+// its place until Score replaces its points with the redress awarded.
+func ScoreRace(results []Result, entries int) []Result {
+	scored := make([]Result, len(results))
+	copy(scored, results)
+
+	var removed []int
+	for _, r := range scored {
+		if !r.Code.KeepsPlace() && r.Position > 0 {
+			removed = append(removed, r.Position)
+		}
+	}
+
+	places := make([]int, len(scored))
+	tied := make(map[int]int)
+	for i, r := range scored {
+		if !r.Code.KeepsPlace() || r.Position == 0 {
+			continue
+		}
+		places[i] = r.Position
+		for _, p := range removed {
+			if p < r.Position {
+				places[i]--
+			}
+		}
+		tied[places[i]]++
+	}
+
+	for i, r := range scored {
+		if !r.Code.KeepsPlace() || r.Position == 0 {
+			scored[i].Points = r.Code.Points(entries)
+			continue
+		}
+		n := tied[places[i]]
+		var sum float64
+		for p := places[i]; p < places[i]+n; p++ {
+			sum += PointsForPlace(p)
+		}
+		scored[i].Points = sum / float64(n)
+
+		if penalty := penaltyPoints(r, entries); penalty > 0 {
+			scored[i].Points = math.Min(scored[i].Points+penalty, DNF.Points(entries))
+		}
+	}
+
+	return scored
+}
+
+// Score scores every race in results, sums the points of each boat and drops
+// the worst results according to the discard schedule. A boat without a
+// result in a race the others sailed scores DNC (RRS A5.2). Standings are
+// returned in ranking order with ties broken per RRS A8.
+func Score(results []Result, config Config) []Standing {
+	races := make(map[int][]Result)
+	var raceNumbers []int
+	teams := make(map[string]bool)
+	var teamIDs []string
+	sailed := make(map[int]map[string]bool)
+	for _, r := range results {
+		if !teams[r.TeamID] {
+			teams[r.TeamID] = true
+			teamIDs = append(teamIDs, r.TeamID)
+		}
+		if _, exists := races[r.RaceNumber]; !exists {
+			raceNumbers = append(raceNumbers, r.RaceNumber)
+			sailed[r.RaceNumber] = make(map[string]bool)
+		}
+		races[r.RaceNumber] = append(races[r.RaceNumber], r)
+		sailed[r.RaceNumber][r.TeamID] = true
+	}
+	sort.Ints(raceNumbers)
+
+	for _, number := range raceNumbers {
+		for _, teamID := range teamIDs {
+			if !sailed[number][teamID] {
+				races[number] = append(races[number], Result{TeamID: teamID, RaceNumber: number, Code: DNC})
+			}
+		}
+	}
+
+	entries := config.Entries
+	if entries < len(teams) {
+		entries = len(teams)
+	}
+
+	standings := make(map[string]*Standing)
+	var order []string
+	for _, number := range raceNumbers {
+		for _, r := range ScoreRace(races[number], entries) {
+			s, exists := standings[r.TeamID]
+			if !exists {
+				s = &Standing{TeamID: r.TeamID}
+				standings[r.TeamID] = s
+				order = append(order, r.TeamID)
+			}
+			s.Results = append(s.Results, r)
+			s.GrossPoints += r.Points
+		}
+	}
+
+	n := config.Discards.Discards(len(raceNumbers))
+	list := make([]Standing, 0, len(order))
+	for _, teamID := range order {
+		s := standings[teamID]
+		applyRedress(s)
+		applyDiscards(s, n)
+		list = append(list, *s)
+	}
+	rank(list, raceNumbers)
+
+	return list
+}
@@ -0,0 +1,208 @@
+package scoring
+
+import "testing"
+
+// racePoints returns the points scored in a race by team.
+func racePoints(results []Result) map[string]float64 {
+	points := make(map[string]float64)
+	for _, r := range results {
+		points[r.TeamID] = r.Points
+	}
+	return points
+}
+
+func TestScoreRace(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries int
+		results []Result
+		want    map[string]float64
+	}{
+		{
+			name:    "places score their position",
+			entries: 3,
+			results: []Result{{TeamID: "A", Position: 1}, {TeamID: "B", Position: 2}, {TeamID: "C", Position: 3}},
+			want:    map[string]float64{"A": 1, "B": 2, "C": 3},
+		},
+		{
+			name:    "codes score entries plus one",
+			entries: 5,
+			results: []Result{{TeamID: "A", Position: 1}, {TeamID: "B", Code: DNF}, {TeamID: "C", Code: DNC}},
+			want:    map[string]float64{"A": 1, "B": 6, "C": 6},
+		},
+		{
+			name:    "finishers behind a disqualified boat move up",
+			entries: 3,
+			results: []Result{{TeamID: "A", Position: 1}, {TeamID: "B", Position: 2, Code: DSQ}, {TeamID: "C", Position: 3}},
+			want:    map[string]float64{"A": 1, "B": 4, "C": 2},
+		},
+		{
+			name:    "two tied boats share the average of their places",
+			entries: 4,
+			results: []Result{{TeamID: "A", Position: 1}, {TeamID: "B", Position: 2}, {TeamID: "C", Position: 2}, {TeamID: "D", Position: 4}},
+			want:    map[string]float64{"A": 1, "B": 2.5, "C": 2.5, "D": 4},
+		},
+		{
+			name:    "three tied boats share the average of their places",
+			entries: 4,
+			results: []Result{{TeamID: "A", Position: 1}, {TeamID: "B", Position: 2}, {TeamID: "C", Position: 2}, {TeamID: "D", Position: 2}},
+			want:    map[string]float64{"A": 1, "B": 3, "C": 3, "D": 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := racePoints(ScoreRace(tt.results, tt.entries))
+			for team, want := range tt.want {
+				if got[team] != want {
+					t.Errorf("%s scored %v, want %v", team, got[team], want)
+				}
+			}
+		})
+	}
+}
+
+func TestScoreDiscards(t *testing.T) {
+	tests := []struct {
+		name      string
+		team      string
+		config    Config
+		results   []Result
+		gross     float64
+		net       float64
+		discarded []int
+	}{
+		{
+			name:   "no discard before the threshold",
+			config: Config{Discards: DiscardSchedule{3}},
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Position: 1}, {TeamID: "B", RaceNumber: 1, Position: 2},
+				{TeamID: "A", RaceNumber: 2, Position: 2}, {TeamID: "B", RaceNumber: 2, Position: 1},
+			},
+			gross: 3,
+			net:   3,
+		},
+		{
+			name:   "worst race is discarded",
+			config: Config{Entries: 5, Discards: DiscardSchedule{3}},
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Position: 1},
+				{TeamID: "A", RaceNumber: 2, Position: 5},
+				{TeamID: "A", RaceNumber: 3, Position: 2},
+			},
+			gross:     8,
+			net:       3,
+			discarded: []int{2},
+		},
+		{
+			name:   "later race is discarded among equal scores",
+			config: Config{Discards: DiscardSchedule{2}},
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Position: 2}, {TeamID: "B", RaceNumber: 1, Position: 1},
+				{TeamID: "A", RaceNumber: 2, Position: 2}, {TeamID: "B", RaceNumber: 2, Position: 1},
+			},
+			gross:     4,
+			net:       2,
+			discarded: []int{2},
+		},
+		{
+			name:   "DNE is never discarded",
+			config: Config{Entries: 3, Discards: DiscardSchedule{2}},
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Code: DNE},
+				{TeamID: "A", RaceNumber: 2, Position: 3},
+			},
+			gross:     7,
+			net:       4,
+			discarded: []int{2},
+		},
+		{
+			name:   "missing races score DNC",
+			team:   "C",
+			config: Config{Discards: DiscardSchedule{3}},
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Position: 1}, {TeamID: "B", RaceNumber: 1, Position: 2}, {TeamID: "C", RaceNumber: 1, Position: 3},
+				{TeamID: "A", RaceNumber: 2, Position: 1}, {TeamID: "B", RaceNumber: 2, Position: 2},
+				{TeamID: "A", RaceNumber: 3, Position: 1}, {TeamID: "B", RaceNumber: 3, Position: 2},
+			},
+			gross:     11,
+			net:       7,
+			discarded: []int{3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			team := tt.team
+			if team == "" {
+				team = "A"
+			}
+			var got *Standing
+			standings := Score(tt.results, tt.config)
+			for i := range standings {
+				if standings[i].TeamID == team {
+					got = &standings[i]
+				}
+			}
+			if got == nil {
+				t.Fatalf("%s has no standing", team)
+			}
+			if got.GrossPoints != tt.gross || got.NetPoints != tt.net {
+				t.Errorf("%s scored %v gross, %v net, want %v and %v", team, got.GrossPoints, got.NetPoints, tt.gross, tt.net)
+			}
+
+			want := make(map[int]bool)
+			for _, race := range tt.discarded {
+				want[race] = true
+			}
+			for _, r := range got.Results {
+				if r.Discarded != want[r.RaceNumber] {
+					t.Errorf("race %d discarded = %v, want %v", r.RaceNumber, r.Discarded, want[r.RaceNumber])
+				}
+			}
+		})
+	}
+}
+
+func TestParseDiscardSchedule(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "", want: ""},
+		{input: "4", want: "4"},
+		{input: " 4, 8 ", want: "4,8"},
+		{input: "8,4", wantErr: true},
+		{input: "4,4", wantErr: true},
+		{input: "0", wantErr: true},
+		{input: "four", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			schedule, err := ParseDiscardSchedule(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %v, want an error", schedule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if schedule.String() != tt.want {
+				t.Errorf("got %q, want %q", schedule.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscards(t *testing.T) {
+	schedule := DiscardSchedule{4, 8}
+	for races, want := range map[int]int{0: 0, 3: 0, 4: 1, 7: 1, 8: 2, 12: 2} {
+		if got := schedule.Discards(races); got != want {
+			t.Errorf("%d races: got %d discards, want %d", races, got, want)
+		}
+	}
+}
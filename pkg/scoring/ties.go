@@ -0,0 +1,82 @@
+package scoring
+
+import (
+	"math"
+	"sort"
+)
+
+// rank orders standings by net points, breaks ties per RRS A8 and assigns
+// ranks. Boats still tied after A8.2 share the same rank.
+func rank(list []Standing, raceNumbers []int) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return compare(list[i], list[j], raceNumbers) < 0
+	})
+
+	for i := range list {
+		if i > 0 && compare(list[i-1], list[i], raceNumbers) == 0 {
+			list[i].Rank = list[i-1].Rank
+		} else {
+			list[i].Rank = i + 1
+		}
+	}
+}
+
+// compare returns a negative number when a ranks ahead of b, a positive
+// number when b ranks ahead of a and zero when they remain tied.
+func compare(a, b Standing, raceNumbers []int) int {
+	if a.NetPoints != b.NetPoints {
+		return sign(a.NetPoints - b.NetPoints)
+	}
+
+	// A8.1: compare race scores, excluding discards, from best to worst
+	sa, sb := countedScores(a), countedScores(b)
+	for i := 0; i < len(sa) && i < len(sb); i++ {
+		if sa[i] != sb[i] {
+			return sign(sa[i] - sb[i])
+		}
+	}
+
+	// A8.2: compare scores in the last race, then the race before and so on,
+	// including discarded scores
+	for i := len(raceNumbers) - 1; i >= 0; i-- {
+		x, y := scoreInRace(a, raceNumbers[i]), scoreInRace(b, raceNumbers[i])
+		if x != y {
+			return sign(x - y)
+		}
+	}
+
+	return 0
+}
+
+// countedScores returns the non-discarded scores of a standing, best first.
+func countedScores(s Standing) []float64 {
+	var scores []float64
+	for _, r := range s.Results {
+		if !r.Discarded {
+			scores = append(scores, r.Points)
+		}
+	}
+	sort.Float64s(scores)
+	return scores
+}
+
+// scoreInRace returns the points a standing scored in a race. A race
+// without a result counts as worse than any score.
+func scoreInRace(s Standing, raceNumber int) float64 {
+	for _, r := range s.Results {
+		if r.RaceNumber == raceNumber {
+			return r.Points
+		}
+	}
+	return math.Inf(1)
+}
+
+func sign(x float64) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,75 @@
+package scoring
+
+import "testing"
+
+func TestScoreTieBreaks(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		results []Result
+		order   []string
+		ranks   []int
+	}{
+		{
+			name: "net points rank first",
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Position: 2}, {TeamID: "B", RaceNumber: 1, Position: 1},
+				{TeamID: "A", RaceNumber: 2, Position: 2}, {TeamID: "B", RaceNumber: 2, Position: 1},
+			},
+			order: []string{"B", "A"},
+			ranks: []int{1, 2},
+		},
+		{
+			name: "A8.1 best scores, then A8.2 last race",
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Position: 1}, {TeamID: "B", RaceNumber: 1, Position: 2}, {TeamID: "C", RaceNumber: 1, Position: 3},
+				{TeamID: "A", RaceNumber: 2, Position: 3}, {TeamID: "B", RaceNumber: 2, Position: 2}, {TeamID: "C", RaceNumber: 2, Position: 1},
+			},
+			order: []string{"C", "A", "B"},
+			ranks: []int{1, 2, 3},
+		},
+		{
+			name: "A8.2 last race when scores are the same",
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Position: 1}, {TeamID: "B", RaceNumber: 1, Position: 2},
+				{TeamID: "A", RaceNumber: 2, Position: 2}, {TeamID: "B", RaceNumber: 2, Position: 1},
+			},
+			order: []string{"B", "A"},
+			ranks: []int{1, 2},
+		},
+		{
+			name:   "A8.1 leaves out discards",
+			config: Config{Entries: 5, Discards: DiscardSchedule{3}},
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Code: DNF}, {TeamID: "B", RaceNumber: 1, Position: 4},
+				{TeamID: "A", RaceNumber: 2, Position: 3}, {TeamID: "B", RaceNumber: 2, Position: 2},
+				{TeamID: "A", RaceNumber: 3, Position: 2}, {TeamID: "B", RaceNumber: 3, Position: 3},
+			},
+			order: []string{"A", "B"},
+			ranks: []int{1, 2},
+		},
+		{
+			name: "boats tied after A8.2 share the rank",
+			results: []Result{
+				{TeamID: "A", RaceNumber: 1, Position: 1}, {TeamID: "B", RaceNumber: 1, Position: 1}, {TeamID: "C", RaceNumber: 1, Position: 3},
+				{TeamID: "A", RaceNumber: 2, Position: 1}, {TeamID: "B", RaceNumber: 2, Position: 1}, {TeamID: "C", RaceNumber: 2, Position: 3},
+			},
+			order: []string{"A", "B", "C"},
+			ranks: []int{1, 1, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standings := Score(tt.results, tt.config)
+			if len(standings) != len(tt.order) {
+				t.Fatalf("got %d standings, want %d", len(standings), len(tt.order))
+			}
+			for i, standing := range standings {
+				if standing.TeamID != tt.order[i] || standing.Rank != tt.ranks[i] {
+					t.Errorf("place %d: got %s ranked %d, want %s ranked %d", i+1, standing.TeamID, standing.Rank, tt.order[i], tt.ranks[i])
+				}
+			}
+		})
+	}
+}
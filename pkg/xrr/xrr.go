@@ -0,0 +1,199 @@
+package xrr
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Document is a World Sailing (formerly ISAF) XML Results Reporting file.
+// People, boats and teams are listed once at the top and referred to by ID
+// from the event's divisions.
+type Document struct {
+	XMLName xml.Name `xml:"SailingXRR"`
+	Type    string   `xml:"Type,attr"`
+	Version string   `xml:"Version,attr"`
+	Date    string   `xml:"Date,attr"`
+	Time    string   `xml:"Time,attr"`
+	Persons []Person `xml:"Person"`
+	Boats   []Boat   `xml:"Boat"`
+	Teams   []Team   `xml:"Team"`
+	Event   Event    `xml:"Event"`
+}
+
+type Person struct {
+	PersonID   string `xml:"PersonID,attr"`
+	GivenName  string `xml:"GivenName,attr"`
+	FamilyName string `xml:"FamilyName,attr"`
+	NOC        string `xml:"NOC,attr,omitempty"`
+}
+
+type Boat struct {
+	BoatID     string `xml:"BoatID,attr"`
+	SailNumber string `xml:"SailNumber,attr"`
+	BoatName   string `xml:"BoatName,attr,omitempty"`
+}
+
+type Team struct {
+	TeamID   string `xml:"TeamID,attr"`
+	BoatID   string `xml:"BoatID,attr"`
+	NOC      string `xml:"NOC,attr"`
+	TeamName string `xml:"TeamName,attr,omitempty"`
+	Club     string `xml:"Club,attr,omitempty"`
+	Crew     []Crew `xml:"Crew"`
+}
+
+// Crew places a person on a team; Position is "S" for the skipper (helm)
+// and "C" for crew.
+type Crew struct {
+	PersonID string `xml:"PersonID,attr"`
+	Position string `xml:"Position,attr"`
+}
+
+type Event struct {
+	EventID   string     `xml:"EventID,attr"`
+	Title     string     `xml:"Title,attr"`
+	StartDate string     `xml:"StartDate,attr"`
+	EndDate   string     `xml:"EndDate,attr"`
+	Venue     string     `xml:"Venue,attr,omitempty"`
+	Divisions []Division `xml:"Division"`
+}
+
+// Division is a fleet scored on its own, with its races and the series
+// result of each team.
+type Division struct {
+	DivisionID string     `xml:"DivisionID,attr"`
+	Title      string     `xml:"Title,attr"`
+	BoatClass  string     `xml:"BoatClass,attr,omitempty"`
+	Races      []Race     `xml:"Race"`
+	Results    []TRResult `xml:"TRResult"`
+}
+
+type Race struct {
+	RaceID     string `xml:"RaceID,attr"`
+	RaceNumber int    `xml:"RaceNumber,attr"`
+	RaceName   string `xml:"RaceName,attr"`
+	Start      string `xml:"RaceStartTime,attr,omitempty"`
+	RaceStatus string `xml:"RaceStatus,attr"`
+}
+
+// TRResult is a team's series result in a division.
+type TRResult struct {
+	TeamID      string       `xml:"TeamID,attr"`
+	Rank        int          `xml:"Rank,attr"`
+	TotalPoints float64      `xml:"TotalPoints,attr"`
+	NetPoints   float64      `xml:"NetPoints,attr"`
+	RaceResults []RaceResult `xml:"RaceResult"`
+}
+
+type RaceResult struct {
+	RaceID    string  `xml:"RaceID,attr"`
+	Rank      int     `xml:"Rank,attr,omitempty"`
+	Points    float64 `xml:"RacePoints,attr"`
+	ScoreCode string  `xml:"ScoreCode,attr,omitempty"`
+	Discard   bool    `xml:"Discard,attr"`
+}
+
+// Problem is a mandatory field missing from a document, or a reference to
+// something the document does not contain.
+type Problem struct {
+	Element string `json:"element"`
+	ID      string `json:"id,omitempty"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Write writes the document as indented XML with an XML declaration.
+func (d *Document) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(d); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Validate reports the mandatory fields missing from the document: event
+// title and dates, sail numbers, team nationalities and skipper names, and
+// references to unknown teams, boats, people or races.
+func (d *Document) Validate() []Problem {
+	problems := []Problem{}
+	missing := func(element, id, field string) {
+		problems = append(problems, Problem{Element: element, ID: id, Field: field, Message: fmt.Sprintf("%s is required", field)})
+	}
+	unknown := func(element, id, field, ref string) {
+		problems = append(problems, Problem{Element: element, ID: id, Field: field, Message: fmt.Sprintf("%s %q does not exist", field, ref)})
+	}
+
+	if d.Event.Title == "" {
+		missing("Event", d.Event.EventID, "Title")
+	}
+	if d.Event.StartDate == "" {
+		missing("Event", d.Event.EventID, "StartDate")
+	}
+	if d.Event.EndDate == "" {
+		missing("Event", d.Event.EventID, "EndDate")
+	}
+
+	persons := make(map[string]bool)
+	for _, person := range d.Persons {
+		persons[person.PersonID] = true
+		if person.FamilyName == "" {
+			missing("Person", person.PersonID, "FamilyName")
+		}
+	}
+
+	boats := make(map[string]bool)
+	for _, boat := range d.Boats {
+		boats[boat.BoatID] = true
+		if boat.SailNumber == "" {
+			missing("Boat", boat.BoatID, "SailNumber")
+		}
+	}
+
+	teams := make(map[string]bool)
+	for _, team := range d.Teams {
+		teams[team.TeamID] = true
+		if !boats[team.BoatID] {
+			unknown("Team", team.TeamID, "BoatID", team.BoatID)
+		}
+		if team.NOC == "" {
+			missing("Team", team.TeamID, "NOC")
+		}
+		skipper := false
+		for _, crew := range team.Crew {
+			if !persons[crew.PersonID] {
+				unknown("Team", team.TeamID, "PersonID", crew.PersonID)
+			}
+			if crew.Position == "S" {
+				skipper = true
+			}
+		}
+		if !skipper {
+			missing("Team", team.TeamID, "Skipper")
+		}
+	}
+
+	for _, division := range d.Event.Divisions {
+		races := make(map[string]bool)
+		for _, race := range division.Races {
+			races[race.RaceID] = true
+		}
+		for _, result := range division.Results {
+			if !teams[result.TeamID] {
+				unknown("TRResult", result.TeamID, "TeamID", result.TeamID)
+			}
+			for _, raceResult := range result.RaceResults {
+				if !races[raceResult.RaceID] {
+					unknown("RaceResult", result.TeamID, "RaceID", raceResult.RaceID)
+				}
+			}
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,132 @@
+package xrr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// validDocument returns a document with every mandatory field.
+func validDocument() *Document {
+	return &Document{
+		XMLName: xml.Name{Local: "SailingXRR"},
+		Type:    "Results",
+		Version: "1.0",
+		Date:    "2026-04-02",
+		Time:    "18:00:00",
+		Persons: []Person{{PersonID: "P1", GivenName: "Ann", FamilyName: "Lee", NOC: "GBR"}},
+		Boats:   []Boat{{BoatID: "B1", SailNumber: "GBR 1", BoatName: "Swift"}},
+		Teams:   []Team{{TeamID: "T1", BoatID: "B1", NOC: "GBR", TeamName: "Swift", Crew: []Crew{{PersonID: "P1", Position: "S"}}}},
+		Event: Event{
+			EventID:   "E1",
+			Title:     "Spring Cup",
+			StartDate: "2026-04-01",
+			EndDate:   "2026-04-02",
+			Divisions: []Division{{
+				DivisionID: "D1",
+				Title:      "Gold",
+				BoatClass:  "Laser",
+				Races: []Race{
+					{RaceID: "R1", RaceNumber: 1, RaceName: "R1", Start: "2026-04-01T10:00:00Z", RaceStatus: "FINISHED"},
+					{RaceID: "R2", RaceNumber: 2, RaceName: "R2", RaceStatus: "FINISHED"},
+				},
+				Results: []TRResult{{
+					TeamID:      "T1",
+					Rank:        1,
+					TotalPoints: 9.5,
+					NetPoints:   2.5,
+					RaceResults: []RaceResult{
+						{RaceID: "R1", Rank: 1, Points: 2.5},
+						{RaceID: "R2", Points: 7, ScoreCode: "DNF", Discard: true},
+					},
+				}},
+			}},
+		},
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	doc := validDocument()
+
+	var file bytes.Buffer
+	if err := doc.Write(&file); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(file.String(), xml.Header) {
+		t.Error("missing XML declaration")
+	}
+
+	var got Document
+	if err := xml.Unmarshal(file.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&got, doc) {
+		t.Errorf("read back\n%+v\nwant\n%+v", got, *doc)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(d *Document)
+		want   []Problem
+	}{
+		{
+			name:   "complete",
+			change: func(d *Document) {},
+			want:   []Problem{},
+		},
+		{
+			name:   "event title and dates",
+			change: func(d *Document) { d.Event.Title, d.Event.StartDate, d.Event.EndDate = "", "", "" },
+			want: []Problem{
+				{Element: "Event", ID: "E1", Field: "Title", Message: "Title is required"},
+				{Element: "Event", ID: "E1", Field: "StartDate", Message: "StartDate is required"},
+				{Element: "Event", ID: "E1", Field: "EndDate", Message: "EndDate is required"},
+			},
+		},
+		{
+			name:   "sail number",
+			change: func(d *Document) { d.Boats[0].SailNumber = "" },
+			want:   []Problem{{Element: "Boat", ID: "B1", Field: "SailNumber", Message: "SailNumber is required"}},
+		},
+		{
+			name:   "nationality and skipper",
+			change: func(d *Document) { d.Teams[0].NOC, d.Teams[0].Crew[0].Position = "", "C" },
+			want: []Problem{
+				{Element: "Team", ID: "T1", Field: "NOC", Message: "NOC is required"},
+				{Element: "Team", ID: "T1", Field: "Skipper", Message: "Skipper is required"},
+			},
+		},
+		{
+			name:   "unknown boat and person",
+			change: func(d *Document) { d.Teams[0].BoatID, d.Teams[0].Crew[0].PersonID = "B2", "P2" },
+			want: []Problem{
+				{Element: "Team", ID: "T1", Field: "BoatID", Message: `BoatID "B2" does not exist`},
+				{Element: "Team", ID: "T1", Field: "PersonID", Message: `PersonID "P2" does not exist`},
+			},
+		},
+		{
+			name: "unknown team and race",
+			change: func(d *Document) {
+				d.Event.Divisions[0].Results[0].TeamID, d.Event.Divisions[0].Races[1].RaceID = "T2", "R3"
+			},
+			want: []Problem{
+				{Element: "TRResult", ID: "T2", Field: "TeamID", Message: `TeamID "T2" does not exist`},
+				{Element: "RaceResult", ID: "T2", Field: "RaceID", Message: `RaceID "R2" does not exist`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := validDocument()
+			tt.change(doc)
+			if got := doc.Validate(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
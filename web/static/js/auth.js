@@ -0,0 +1,107 @@
+// Sign in to the API: the session token is kept in local storage and sent
+// as a bearer token with every API request. Without one the pages are
+// read-only.
+const AUTH_API_URL = 'https://regatta-project.onrender.com/api';
+//const AUTH_API_URL = 'http://localhost:8081/api'
+const AUTH_STORAGE_KEY = 'regattaSession';
+
+function getSession() {
+    const session = JSON.parse(localStorage.getItem(AUTH_STORAGE_KEY) || 'null');
+    if (session && new Date(session.expiresAt) <= new Date()) {
+        localStorage.removeItem(AUTH_STORAGE_KEY);
+        return null;
+    }
+    return session;
+}
+
+// Add the session token to requests to the API
+const unauthenticatedFetch = window.fetch.bind(window);
+window.fetch = async function(resource, options = {}) {
+    const session = getSession();
+    const url = resource instanceof Request ? resource.url : String(resource);
+    if (session && url.startsWith(AUTH_API_URL)) {
+        const headers = new Headers(options.headers || {});
+        headers.set('Authorization', `Bearer ${session.token}`);
+        options = { ...options, headers };
+    }
+
+    const response = await unauthenticatedFetch(resource, options);
+    if (response.status === 401 && session) {
+        // The session has expired or was ended elsewhere
+        localStorage.removeItem(AUTH_STORAGE_KEY);
+        showSession();
+    }
+    return response;
+};
+
+// The message of an API error response, whose body is {"error": ..., "status": ...}
+async function apiErrorMessage(response) {
+    const body = await response.text();
+    try {
+        return JSON.parse(body).error || response.statusText;
+    } catch (err) {
+        return body || response.statusText;
+    }
+}
+
+async function login(event) {
+    event.preventDefault();
+    const error = document.getElementById('loginError');
+    error.classList.add('d-none');
+
+    try {
+        const response = await fetch(`${AUTH_API_URL}/auth/login`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({
+                username: document.getElementById('loginUsername').value,
+                password: document.getElementById('loginPassword').value
+            })
+        });
+        if (!response.ok) {
+            throw new Error(await apiErrorMessage(response));
+        }
+        localStorage.setItem(AUTH_STORAGE_KEY, JSON.stringify(await response.json()));
+        window.location.href = '/';
+    } catch (err) {
+        console.error('Error signing in:', err);
+        error.textContent = err.message;
+        error.classList.remove('d-none');
+    }
+}
+
+async function logout(event) {
+    event.preventDefault();
+    if (getSession()) {
+        try {
+            await fetch(`${AUTH_API_URL}/auth/logout`, { method: 'POST' });
+        } catch (err) {
+            console.error('Error signing out:', err);
+        }
+    }
+    localStorage.removeItem(AUTH_STORAGE_KEY);
+    window.location.href = '/login';
+}
+
+// Function to show who is signed in, and the sign in or sign out link
+function showSession() {
+    const session = getSession();
+    const user = document.getElementById('sessionUser');
+    const link = document.getElementById('sessionLink');
+    if (!user || !link) return;
+
+    user.textContent = session ? `${session.user.username} (${session.user.role.toLowerCase().replace('_', ' ')})` : '';
+    link.innerHTML = session
+        ? '<i class="bi bi-box-arrow-left"></i> Logout'
+        : '<i class="bi bi-box-arrow-in-right"></i> Sign In';
+    link.href = session ? '#' : '/login';
+    link.onclick = session ? logout : null;
+}
+
+document.addEventListener('DOMContentLoaded', () => {
+    showSession();
+    const form = document.getElementById('loginForm');
+    if (form) {
+        form.addEventListener('submit', login);
+    }
+});
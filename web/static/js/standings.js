@@ -1,150 +1,249 @@
-// Define API base URL
-const API_BASE_URL = 'https://regatta-project.onrender.com/api';
-//const API_BASE_URL = 'http://localhost:8081/api'
-
-async function loadStandings() {
-    const select = document.getElementById('standingsRegattaSelect');
-    if (!select) return; // Exit if element doesn't exist
-
-    try {
-        console.log('Fetching regattas from:', `${API_BASE_URL}/regattas`);
-        const response = await fetch(`${API_BASE_URL}/regattas`);
-        if (!response.ok) {
-            throw new Error(`HTTP error! status: ${response.status}`);
-        }
-        const regattas = await response.json();
-
-        select.innerHTML = '<option value="">Select Regatta</option>'; // Clear previous options
-        regattas.forEach(regatta => {
-            select.innerHTML += `<option value="${regatta.id}">${regatta.name}</option>`;
-        });
-
-        // Load standings for the first regatta by default if available
-        if (regattas.length > 0) {
-            loadCurrentStandings(regattas[0].id);
-        }
-
-        // Set up the event listener for the dropdown
-        select.addEventListener('change', function() {
-            const selectedRegattaId = this.value;
-            if (selectedRegattaId) {
-                loadCurrentStandings(selectedRegattaId);
-            } else {
-                // Optionally clear the standings if no regatta is selected
-                document.getElementById('currentStandings').innerHTML = '<p>Please select a regatta.</p>';
-            }
-        });
-    } catch (error) {
-        console.error('Error loading regattas:', error);
-    }
-}
-
-// Function to load standings for a specific regatta
-async function loadCurrentStandings(regattaId) {
-    if (!regattaId) {
-        console.error('No regattaId provided to loadCurrentStandings');
-        return;
-    }
-
-    try {
-        const standingsUrl = `${API_BASE_URL}/regattas/${regattaId}/standings`;
-        console.log('Fetching current standings from:', standingsUrl);
-
-        const response = await fetch(standingsUrl);
-        if (!response.ok) {
-            throw new Error(`HTTP error! status: ${response.status}`);
-        }
-
-        const standings = await response.json();
-        console.log('Received standings:', standings);
-
-        const standingsContainer = document.getElementById('standingsTable');
-        if (!standingsContainer) {
-            console.log('Standings container element not found');
-            return;
-        }
-
-        standingsContainer.innerHTML = ''; // Clear previous standings
-
-        if (!Array.isArray(standings) || standings.length === 0) {
-            standingsContainer.innerHTML = '<p>No standings available for this regatta</p>';
-            return;
-        }
-
-        // Sort teams by total points (ascending)
-        standings.sort((a, b) => a.totalPoints - b.totalPoints);
-
-        // Create a table for standings
-        let tableHTML = `
-            <table class="standings-table">
-                <thead>
-                    <tr>
-                        <th>Team</th>
-                        <th>Total Points</th>
-        `;
-
-        // Assuming the first team has all the races, we can get the races from the first team's results
-        const races = standings[0].results.map(result => result.raceNumber);
-        races.forEach(race => {
-            tableHTML += `<th>Race ${race}</th>`;
-        });
-
-        tableHTML += `
-                    </tr>
-                </thead>
-                <tbody>
-        `;
-
-        standings.forEach(team => {
-            tableHTML += `
-                <tr>
-                    <td>${team.name}</td>
-                    <td>${team.totalPoints}</td>
-            `;
-
-            // Create a cell for each race
-            races.forEach(race => {
-                const result = team.results.find(r => r.raceNumber === race);
-                const positionIcon = result ? getPositionIcon(result.position) : 'N/A'; // Display icon or N/A if no result
-                tableHTML += `<td>${positionIcon} ${result ? result.position : 'N/A'}</td>`;
-            });
-
-            tableHTML += `
-                </tr>
-            `;
-        });
-
-        tableHTML += `
-                </tbody>
-            </table>
-        `;
-
-        standingsContainer.innerHTML = tableHTML; // Insert the table into the container
-
-        // Set the dropdown to the currently selected regatta
-        const select = document.getElementById('standingsRegattaSelect');
-        if (select) {
-            select.value = regattaId; // Set the dropdown to the current regatta
-        }
-    } catch (error) {
-        console.error('Error loading current standings:', error);
-        alert('Failed to load standings: ' + error.message);
-    }
-}
-
-// Function to get the position icon based on the position number
-function getPositionIcon(position) {
-    switch (position) {
-        case 1:
-            return '<i class="fas fa-medal" style="color: gold;"></i>'; // Gold medal
-        case 2:
-            return '<i class="fas fa-medal" style="color: silver;"></i>'; // Silver medal
-        case 3:
-            return '<i class="fas fa-medal" style="color: #cd7f32;"></i>'; // Bronze medal
-        default:
-            return ''; // No icon for positions 4 and above
-    }
-}
-
-// Initial call to load standings when the page loads
+// Define API base URL
+const API_BASE_URL = 'https://regatta-project.onrender.com/api';
+//const API_BASE_URL = 'http://localhost:8081/api'
+
+async function loadStandings() {
+    const select = document.getElementById('standingsRegattaSelect');
+    if (!select) return; // Exit if element doesn't exist
+
+    try {
+        console.log('Fetching regattas from:', `${API_BASE_URL}/regattas`);
+        const response = await fetch(`${API_BASE_URL}/regattas`);
+        if (!response.ok) {
+            throw new Error(`HTTP error! status: ${response.status}`);
+        }
+        const regattas = await response.json();
+
+        select.innerHTML = '<option value="">Select Regatta</option>'; // Clear previous options
+        regattas.forEach(regatta => {
+            select.innerHTML += `<option value="${regatta.id}">${regatta.name}</option>`;
+        });
+
+        // Load standings for the regatta in the URL, or the first one
+        const requested = new URLSearchParams(window.location.search).get('regattaId');
+        if (requested) {
+            loadCurrentStandings(requested);
+        } else if (regattas.length > 0) {
+            loadCurrentStandings(regattas[0].id);
+        }
+
+        // Set up the event listener for the dropdown
+        select.addEventListener('change', function() {
+            const selectedRegattaId = this.value;
+            if (selectedRegattaId) {
+                loadCurrentStandings(selectedRegattaId);
+            } else {
+                // Optionally clear the standings if no regatta is selected
+                document.getElementById('currentStandings').innerHTML = '<p>Please select a regatta.</p>';
+            }
+        });
+    } catch (error) {
+        console.error('Error loading regattas:', error);
+    }
+}
+
+// Labels and banner styles of the publication statuses
+const PUBLICATION_STATUS = {
+    PROVISIONAL: { label: 'Provisional results', style: 'alert-warning' },
+    UNDER_PROTEST: { label: 'Under protest', style: 'alert-danger' },
+    FINAL: { label: 'Final results', style: 'alert-success' }
+};
+
+function formatPublicationTime(time) {
+    return new Date(time).toLocaleString([], { dateStyle: 'short', timeStyle: 'short' });
+}
+
+// Function to show the status of the published standings and races
+function showPublicationStatus(published) {
+    const banner = document.getElementById('publicationBanner');
+    const races = document.getElementById('racePublications');
+
+    const publication = published.regatta;
+    banner.classList.remove('d-none', 'alert-warning', 'alert-danger', 'alert-success');
+    if (publication) {
+        const status = PUBLICATION_STATUS[publication.status] || PUBLICATION_STATUS.PROVISIONAL;
+        const deadline = publication.protestDeadline
+            ? `, protest time limit ${formatPublicationTime(publication.protestDeadline)}` : '';
+        banner.classList.add(status.style);
+        banner.innerHTML = `<strong>${status.label}</strong> - published ${formatPublicationTime(publication.publishedAt)}${deadline}`;
+    } else {
+        banner.classList.add('alert-warning');
+        banner.innerHTML = '<strong>Results have not been published yet</strong>';
+    }
+
+    races.innerHTML = (published.races || []).map(race => {
+        const status = PUBLICATION_STATUS[race.status] || PUBLICATION_STATUS.PROVISIONAL;
+        return `<span class="badge publication-badge ${status.style}">Race ${race.raceNumber}: ${status.label}</span>`;
+    }).join(' ');
+}
+
+// Function to load the published standings of a specific regatta
+async function loadCurrentStandings(regattaId) {
+    if (!regattaId) {
+        console.error('No regattaId provided to loadCurrentStandings');
+        return;
+    }
+
+    try {
+        // The public page shows only what has been published
+        const publishedUrl = `${API_BASE_URL}/regattas/${regattaId}/published`;
+        const response = await fetch(publishedUrl);
+        if (!response.ok) {
+            throw new Error(`HTTP error! status: ${response.status}`);
+        }
+
+        const published = await response.json();
+        showPublicationStatus(published);
+        subscribeStandings(regattaId);
+
+        const standings = published.regatta ? published.regatta.standings : [];
+
+        const standingsContainer = document.getElementById('standingsTable');
+        if (!standingsContainer) {
+            console.log('Standings container element not found');
+            return;
+        }
+
+        standingsContainer.innerHTML = ''; // Clear previous standings
+        document.getElementById('resultSheetLinks').classList.add('d-none');
+
+        if (!Array.isArray(standings) || standings.length === 0) {
+            standingsContainer.innerHTML = '<p>No standings available for this regatta</p>';
+            return;
+        }
+
+        // Each fleet is scored separately and gets its own table
+        standings.forEach(fleet => {
+            const heading = fleet.fleetName || (standings.length > 1 ? 'No Fleet' : '');
+            if (heading) {
+                standingsContainer.innerHTML += `<h4 class="fleet-name">${heading}</h4>`;
+            }
+            standingsContainer.innerHTML += buildStandingsTable(fleet.standings);
+        });
+
+        // Link the printable results sheet, which the public are served
+        // from the same published standings
+        const sheetUrl = `${API_BASE_URL}/regattas/${regattaId}/results/sheet`;
+        document.getElementById('resultSheetHtml').href = sheetUrl;
+        document.getElementById('resultSheetPdf').href = `${sheetUrl}?format=pdf`;
+        document.getElementById('resultSheetLinks').classList.remove('d-none');
+
+        // Set the dropdown to the currently selected regatta
+        const select = document.getElementById('standingsRegattaSelect');
+        if (select) {
+            select.value = regattaId; // Set the dropdown to the current regatta
+        }
+    } catch (error) {
+        console.error('Error loading current standings:', error);
+        alert('Failed to load standings: ' + error.message);
+    }
+}
+
+// Live updates of the selected regatta, pushed by the API as Server-Sent Events
+let standingsStream = null;
+let streamRegattaId = null;
+
+// Function to subscribe to the standings stream of a regatta. The page shows
+// published results, so a new publication reloads them while other changes
+// only note that updated results are awaiting publication.
+function subscribeStandings(regattaId) {
+    if (!window.EventSource || streamRegattaId === regattaId) return;
+    if (standingsStream) {
+        standingsStream.close();
+    }
+
+    const liveStatus = document.getElementById('liveStatus');
+    liveStatus.textContent = '';
+    streamRegattaId = regattaId;
+    standingsStream = new EventSource(`${API_BASE_URL}/regattas/${regattaId}/standings/stream`);
+
+    standingsStream.addEventListener('published', () => {
+        liveStatus.textContent = '';
+        loadCurrentStandings(regattaId);
+    });
+    standingsStream.addEventListener('updated', () => {
+        liveStatus.textContent = `Results updated ${formatPublicationTime(new Date())}, awaiting publication`;
+    });
+    standingsStream.onerror = () => {
+        // EventSource reconnects by itself; log so dropped connections are visible
+        console.warn('Standings stream interrupted, reconnecting');
+    };
+}
+
+// Function to build the standings table of one fleet
+function buildStandingsTable(standings) {
+    // Standings arrive ranked by the API, ties already broken
+
+    // Create a table for standings
+    let tableHTML = `
+        <table class="standings-table">
+            <thead>
+                <tr>
+                    <th>Rank</th>
+                    <th>Sail No.</th>
+                    <th>Team</th>
+                    <th>Total Points</th>
+                    <th>Net Points</th>
+    `;
+
+    // Assuming the first team has all the races, we can get the races from the first team's results
+    const races = standings[0].results.map(result => result.raceNumber);
+    races.forEach(race => {
+        tableHTML += `<th>Race ${race}</th>`;
+    });
+
+    tableHTML += `
+                </tr>
+            </thead>
+            <tbody>
+    `;
+
+    standings.forEach(team => {
+        tableHTML += `
+            <tr>
+                <td>${team.rank}</td>
+                <td>${team.sailNumber || ''}</td>
+                <td>${team.name}${team.boatName ? ` (${team.boatName})` : ''}</td>
+                <td>${team.grossPoints}</td>
+                <td>${team.netPoints}</td>
+        `;
+
+        // Create a cell for each race
+        races.forEach(race => {
+            const result = team.results.find(r => r.raceNumber === race);
+            const positionIcon = result ? getPositionIcon(result.position) : 'N/A'; // Display icon or N/A if no result
+            const penalties = result && result.penalties ? result.penalties.map(p => ` ${p.code}`).join('') : '';
+            const score = result ? `${result.code || result.position}${penalties}` : 'N/A';
+            const position = result && result.discarded ? `(${score})` : score; // Discarded results in brackets
+            tableHTML += `<td>${positionIcon} ${position}</td>`;
+        });
+
+        tableHTML += `
+            </tr>
+        `;
+    });
+
+    tableHTML += `
+            </tbody>
+        </table>
+    `;
+
+    return tableHTML;
+}
+
+// Function to get the position icon based on the position number
+function getPositionIcon(position) {
+    switch (position) {
+        case 1:
+            return '<i class="fas fa-medal" style="color: gold;"></i>'; // Gold medal
+        case 2:
+            return '<i class="fas fa-medal" style="color: silver;"></i>'; // Silver medal
+        case 3:
+            return '<i class="fas fa-medal" style="color: #cd7f32;"></i>'; // Bronze medal
+        default:
+            return ''; // No icon for positions 4 and above
+    }
+}
+
+// Initial call to load standings when the page loads
 document.addEventListener('DOMContentLoaded', loadStandings); 
\ No newline at end of file